@@ -0,0 +1,427 @@
+package abi
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypedDataDomainType 域的类型名称
+const TypedDataDomainType = "EIP712Domain"
+
+// TypedDataField 结构体的字段
+//   - Name 字段名称
+//   - Type 字段类型，可以是abi的基本类型、Types中定义的结构体或它们的数组，如 uint256、Person、Person[]、bytes32[2]
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain 签名的域，防止签名在其它链、其它合约中被重放，为空的字段不参与哈希
+//   - Name              应用或协议的名称
+//   - Version           版本
+//   - ChainId           链ID
+//   - VerifyingContract 验证签名的合约地址，支持zltc地址和0x地址
+//   - Salt              32字节的盐
+type TypedDataDomain struct {
+	Name              string   `json:"name,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	ChainId           *big.Int `json:"chainId,omitempty"`
+	VerifyingContract string   `json:"verifyingContract,omitempty"`
+	Salt              string   `json:"salt,omitempty"`
+}
+
+// TypedData 结构化数据，格式与 EIP-712 的 eth_signTypedData_v4 一致
+//   - Types       结构体的定义，可以包含 EIP712Domain
+//   - PrimaryType 签名的结构体类型
+//   - Domain      签名的域
+//   - Message     签名的结构体数据
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      TypedDataDomain             `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData 解析json格式的结构化数据，数字解析为 json.Number，不会丢失精度
+//
+// Parameters:
+//   - data []byte
+//
+// Returns:
+//   - *TypedData
+//   - error
+func ParseTypedData(data []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	typedData := new(TypedData)
+	if err := decoder.Decode(typedData); err != nil {
+		return nil, err
+	}
+	return typedData, nil
+}
+
+// TypedDataHasher 按照 EIP-712 的规则计算结构化数据的哈希，哈希函数与合约中的 keccak256 一致，Sm2p256v1 为 SM3，其它曲线为 Keccak256
+type TypedDataHasher interface {
+	// EncodeType 编码结构体的类型，如 Mail(Person from,Person to,string contents)Person(string name,address wallet)
+	//
+	// Parameters:
+	//   - typedData *TypedData
+	//   - primaryType string: 结构体类型
+	//
+	// Returns:
+	//   - string
+	//   - error: 类型未定义时返回
+	EncodeType(typedData *TypedData, primaryType string) (string, error)
+
+	// TypeHash 计算结构体类型的哈希，hash(EncodeType)
+	//
+	// Parameters:
+	//   - typedData *TypedData
+	//   - primaryType string: 结构体类型
+	//
+	// Returns:
+	//   - common.Hash
+	//   - error
+	TypeHash(typedData *TypedData, primaryType string) (common.Hash, error)
+
+	// HashStruct 计算结构体的哈希，hash(TypeHash || encodeData(data))
+	//
+	// Parameters:
+	//   - typedData *TypedData
+	//   - primaryType string: 结构体类型
+	//   - data map[string]interface{}: 结构体数据
+	//
+	// Returns:
+	//   - common.Hash
+	//   - error
+	HashStruct(typedData *TypedData, primaryType string, data map[string]interface{}) (common.Hash, error)
+
+	// DomainSeparator 计算域的哈希
+	//
+	// Parameters:
+	//   - typedData *TypedData
+	//
+	// Returns:
+	//   - common.Hash
+	//   - error
+	DomainSeparator(typedData *TypedData) (common.Hash, error)
+
+	// Hash 计算待签名的哈希，hash(0x19 || 0x01 || DomainSeparator || HashStruct(message))
+	//
+	// Parameters:
+	//   - typedData *TypedData
+	//
+	// Returns:
+	//   - common.Hash
+	//   - error
+	Hash(typedData *TypedData) (common.Hash, error)
+}
+
+// NewTypedDataHasher 初始化结构化数据的哈希器
+//   - crypto.Secp256k1 keccak256，与EIP-712一致
+//   - crypto.Sm2p256v1 sm3
+//
+// Parameters:
+//   - curve types.Curve: 链使用的椭圆曲线
+//
+// Returns:
+//   - TypedDataHasher
+//   - error: 不支持的曲线为 crypto.ErrUnsupportedCurve
+func NewTypedDataHasher(curve types.Curve) (TypedDataHasher, error) {
+	switch curve {
+	case crypto.Secp256k1:
+		return &typedDataHasher{hash: ethcrypto.Keccak256Hash}, nil
+	case crypto.Sm2p256v1:
+		return &typedDataHasher{hash: sm3Hash}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", crypto.ErrUnsupportedCurve, curve)
+	}
+}
+
+func sm3Hash(data ...[]byte) common.Hash {
+	hasher := sm3.New()
+	for _, b := range data {
+		hasher.Write(b)
+	}
+	return common.BytesToHash(hasher.Sum(nil))
+}
+
+type typedDataHasher struct {
+	hash func(data ...[]byte) common.Hash
+}
+
+func (h *typedDataHasher) EncodeType(typedData *TypedData, primaryType string) (string, error) {
+	if _, ok := typedData.Types[primaryType]; !ok {
+		return "", fmt.Errorf("type %s is not defined", primaryType)
+	}
+	deps := make(map[string]struct{})
+	h.dependencies(typedData, primaryType, deps)
+	delete(deps, primaryType)
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var builder strings.Builder
+	for _, name := range append([]string{primaryType}, sorted...) {
+		builder.WriteString(name)
+		builder.WriteString("(")
+		for i, field := range typedData.Types[name] {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(field.Type)
+			builder.WriteString(" ")
+			builder.WriteString(field.Name)
+		}
+		builder.WriteString(")")
+	}
+	return builder.String(), nil
+}
+
+// 收集结构体引用的所有结构体类型
+func (h *typedDataHasher) dependencies(typedData *TypedData, typeName string, deps map[string]struct{}) {
+	typeName = baseType(typeName)
+	if _, ok := deps[typeName]; ok {
+		return
+	}
+	fields, ok := typedData.Types[typeName]
+	if !ok {
+		return
+	}
+	deps[typeName] = struct{}{}
+	for _, field := range fields {
+		h.dependencies(typedData, field.Type, deps)
+	}
+}
+
+func (h *typedDataHasher) TypeHash(typedData *TypedData, primaryType string) (common.Hash, error) {
+	encodedType, err := h.EncodeType(typedData, primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return h.hash([]byte(encodedType)), nil
+}
+
+func (h *typedDataHasher) HashStruct(typedData *TypedData, primaryType string, data map[string]interface{}) (common.Hash, error) {
+	typeHash, err := h.TypeHash(typedData, primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	encoded := [][]byte{typeHash.Bytes()}
+	for _, field := range typedData.Types[primaryType] {
+		value, ok := data[field.Name]
+		if !ok {
+			return common.Hash{}, fmt.Errorf("field %s.%s is missing", primaryType, field.Name)
+		}
+		encodedValue, err := h.encodeValue(typedData, field.Type, value)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to encode field %s.%s: %w", primaryType, field.Name, err)
+		}
+		encoded = append(encoded, encodedValue)
+	}
+	return h.hash(encoded...), nil
+}
+
+// 编码字段的值为32字节，string、bytes、数组和结构体编码为其哈希，基本类型按abi编码
+func (h *typedDataHasher) encodeValue(typedData *TypedData, typeName string, value interface{}) ([]byte, error) {
+	if strings.HasSuffix(typeName, "]") {
+		index := strings.LastIndex(typeName, "[")
+		elemType := typeName[:index]
+		values := reflect.ValueOf(value)
+		if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+			return nil, fmt.Errorf("type %s expect array value, but got %T", typeName, value)
+		}
+		if length := typeName[index+1 : len(typeName)-1]; length != "" {
+			if n, err := strconv.Atoi(length); err != nil || n != values.Len() {
+				return nil, fmt.Errorf("type %s expect %s elements, but got %d", typeName, length, values.Len())
+			}
+		}
+		encoded := make([][]byte, values.Len())
+		for i := range encoded {
+			elem, err := h.encodeValue(typedData, elemType, values.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = elem
+		}
+		return h.hash(encoded...).Bytes(), nil
+	}
+
+	if _, ok := typedData.Types[typeName]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("type %s expect map value, but got %T", typeName, value)
+		}
+		hash, err := h.HashStruct(typedData, typeName, data)
+		if err != nil {
+			return nil, err
+		}
+		return hash.Bytes(), nil
+	}
+
+	switch typeName {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("type string expect string value, but got %T", value)
+		}
+		return h.hash([]byte(s)).Bytes(), nil
+	case "bytes":
+		if b, ok := value.([]byte); ok {
+			return h.hash(b).Bytes(), nil
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("type bytes expect hex string value, but got %T", value)
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		return h.hash(b).Bytes(), nil
+	}
+
+	abiType, err := abi.NewType(typeName, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("type %s is not defined: %w", typeName, err)
+	}
+	if address, ok := value.(common.Address); ok && abiType.T == abi.AddressTy {
+		return common.LeftPadBytes(address.Bytes(), 32), nil
+	}
+	converted, err := (&latticeFunction{}).ConvertArgument(abiType, value)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: abiType}}.Pack(converted)
+}
+
+func (h *typedDataHasher) DomainSeparator(typedData *TypedData) (common.Hash, error) {
+	domain := typedData.Domain
+	data := make(map[string]interface{})
+	var fields []TypedDataField
+	if domain.Name != "" {
+		data["name"] = domain.Name
+		fields = append(fields, TypedDataField{Name: "name", Type: "string"})
+	}
+	if domain.Version != "" {
+		data["version"] = domain.Version
+		fields = append(fields, TypedDataField{Name: "version", Type: "string"})
+	}
+	if domain.ChainId != nil {
+		data["chainId"] = domain.ChainId
+		fields = append(fields, TypedDataField{Name: "chainId", Type: "uint256"})
+	}
+	if domain.VerifyingContract != "" {
+		data["verifyingContract"] = domain.VerifyingContract
+		fields = append(fields, TypedDataField{Name: "verifyingContract", Type: "address"})
+	}
+	if domain.Salt != "" {
+		data["salt"] = domain.Salt
+		fields = append(fields, TypedDataField{Name: "salt", Type: "bytes32"})
+	}
+
+	// 未定义 EIP712Domain 时由不为空的字段推导
+	if _, ok := typedData.Types[TypedDataDomainType]; !ok {
+		withDomain := *typedData
+		withDomain.Types = make(map[string][]TypedDataField, len(typedData.Types)+1)
+		for name, typeFields := range typedData.Types {
+			withDomain.Types[name] = typeFields
+		}
+		withDomain.Types[TypedDataDomainType] = fields
+		typedData = &withDomain
+	}
+	return h.HashStruct(typedData, TypedDataDomainType, data)
+}
+
+func (h *typedDataHasher) Hash(typedData *TypedData) (common.Hash, error) {
+	domainSeparator, err := h.DomainSeparator(typedData)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	messageHash, err := h.HashStruct(typedData, typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return h.hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), messageHash.Bytes()), nil
+}
+
+// 去掉数组的后缀，Person[][2] -> Person
+func baseType(typeName string) string {
+	if index := strings.Index(typeName, "["); index >= 0 {
+		return typeName[:index]
+	}
+	return typeName
+}
+
+// SignTypedData 对结构化数据签名，合约中可以用 ecrecover 等方式从 Hash 和签名中恢复签名者
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//   - sk *ecdsa.PrivateKey: 私钥
+//   - typedData *TypedData
+//
+// Returns:
+//   - []byte: 签名，格式与 CryptographyApi.Sign 一致
+//   - error
+func SignTypedData(curve types.Curve, sk *ecdsa.PrivateKey, typedData *TypedData) ([]byte, error) {
+	hasher, err := NewTypedDataHasher(curve)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hasher.Hash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	api, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
+	return api.Sign(hash.Bytes(), sk)
+}
+
+// VerifyTypedData 从结构化数据的签名中恢复签名者的地址
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//   - typedData *TypedData
+//   - signature []byte: 签名
+//
+// Returns:
+//   - string: 签名者的zltc地址
+//   - error: 签名无法解析或与数据不匹配时返回
+func VerifyTypedData(curve types.Curve, typedData *TypedData, signature []byte) (string, error) {
+	hasher, err := NewTypedDataHasher(curve)
+	if err != nil {
+		return "", err
+	}
+	hash, err := hasher.Hash(typedData)
+	if err != nil {
+		return "", err
+	}
+	api, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return "", err
+	}
+	address, err := api.SignatureToAddress(hash.Bytes(), signature)
+	if err != nil {
+		return "", err
+	}
+	return convert.AddressToZltc(address), nil
+}
@@ -0,0 +1,157 @@
+package abi
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"testing"
+)
+
+// https://eips.ethereum.org/EIPS/eip-712 中的示例
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestTypedDataHasher_Hash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	assert.NoError(t, err)
+	hasher, err := NewTypedDataHasher(crypto.Secp256k1)
+	assert.NoError(t, err)
+
+	encodedType, err := hasher.EncodeType(typedData, "Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encodedType)
+	domainSeparator, err := hasher.DomainSeparator(typedData)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", domainSeparator.Hex())
+	messageHash, err := hasher.HashStruct(typedData, "Mail", typedData.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", messageHash.Hex())
+	hash, err := hasher.Hash(typedData)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hash.Hex())
+
+	// 未定义 EIP712Domain 时由域的字段推导
+	delete(typedData.Types, TypedDataDomainType)
+	derived, err := hasher.DomainSeparator(typedData)
+	assert.NoError(t, err)
+	assert.Equal(t, domainSeparator, derived)
+
+	// SM3
+	gmHasher, err := NewTypedDataHasher(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	gmHash, err := gmHasher.Hash(typedData)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, gmHash)
+
+	_, err = NewTypedDataHasher("p256")
+	assert.ErrorIs(t, err, crypto.ErrUnsupportedCurve)
+
+	typedData.Message["contents"] = 1
+	_, err = hasher.Hash(typedData)
+	assert.Error(t, err)
+	delete(typedData.Message, "contents")
+	_, err = hasher.Hash(typedData)
+	assert.ErrorContains(t, err, "Mail.contents is missing")
+}
+
+func TestTypedDataHasher_Arrays(t *testing.T) {
+	data := `{
+  "types": {
+    "EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}, {"name": "salt", "type": "bytes32"}],
+    "Item": [{"name": "sku", "type": "bytes32"}, {"name": "quantity", "type": "uint64"}, {"name": "tags", "type": "string[]"}],
+    "Order": [
+      {"name": "buyer", "type": "address"},
+      {"name": "items", "type": "Item[]"},
+      {"name": "approvers", "type": "address[]"},
+      {"name": "price", "type": "int256"},
+      {"name": "paid", "type": "bool"},
+      {"name": "memo", "type": "bytes"}
+    ]
+  },
+  "primaryType": "Order",
+  "domain": {"name": "Lattice Order", "chainId": 1, "salt": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+  "message": {
+    "buyer": "0x5f2be9a02b43f748ee460bf36eed24fafa109920",
+    "items": [
+      {"sku": "0x0000000000000000000000000000000000000000000000000000000000000abc", "quantity": "3", "tags": ["a", "b"]},
+      {"sku": "0x0000000000000000000000000000000000000000000000000000000000000def", "quantity": "1", "tags": []}
+    ],
+    "approvers": ["0x5f2be9a02b43f748ee460bf36eed24fafa109920", "0x0000000000000000000000000000000000000001"],
+    "price": "-100",
+    "paid": true,
+    "memo": "0x010203"
+  }
+}`
+	typedData, err := ParseTypedData([]byte(data))
+	assert.NoError(t, err)
+	hasher, err := NewTypedDataHasher(crypto.Secp256k1)
+	assert.NoError(t, err)
+	hash, err := hasher.Hash(typedData)
+	assert.NoError(t, err)
+
+	// go-ethereum的 apitypes.TypedDataAndHash 对同一数据计算的哈希
+	assert.Equal(t, "0x8410ea2ff48d7b2e234bd3d1dc183124d9bcbcee3435ea17881ccbd929026e94", hash.Hex())
+
+	// 地址也可以使用zltc格式
+	typedData.Message["buyer"] = "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi"
+	zltcHash, err := hasher.Hash(typedData)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, zltcHash)
+
+	typedData.Types["Order"][2].Type = "address[3]"
+	_, err = hasher.Hash(typedData)
+	assert.ErrorContains(t, err, "expect 3 elements")
+}
+
+func TestSignTypedData(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	assert.NoError(t, err)
+	for _, curve := range []types.Curve{crypto.Secp256k1, crypto.Sm2p256v1} {
+		api := crypto.NewCrypto(curve)
+		sk, err := api.GenerateKeyPair()
+		assert.NoError(t, err)
+		address, err := api.PKToAddress(&sk.PublicKey)
+		assert.NoError(t, err)
+
+		signature, err := SignTypedData(curve, sk, typedData)
+		assert.NoError(t, err)
+		signer, err := VerifyTypedData(curve, typedData, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, convert.AddressToZltc(address), signer)
+
+		typedData.Domain.Version = "2"
+		signer, _ = VerifyTypedData(curve, typedData, signature)
+		assert.NotEqual(t, convert.AddressToZltc(address), signer)
+		typedData.Domain.Version = "1"
+	}
+}
@@ -1,11 +1,29 @@
 package errs
 
+import "github.com/wylu1037/lattice-go/common/codes"
+
 const (
 	ErrInvalidAddressCode = 5001
 	ErrMarshallStructCode = 5002
+
+	ErrInsufficientBalanceCode = 5101
+	ErrHeightConflictCode      = 5102
+	ErrContractNotFoundCode    = 5103
+	ErrUnauthorizedCode        = 5104
+	ErrTimeoutCode             = 5105
+	ErrInvalidParamsCode       = 5106
+	ErrMethodNotFoundCode      = 5107
 )
 
 var (
-	ErrInvalidAddressFormat = NewError(ErrInvalidAddressCode, "The address format is invalid", "地址格式不合法")
-	ErrMarshallStruct       = NewError(ErrMarshallStructCode, "Serialization structure error", "序列化结构体错误")
+	ErrInvalidAddressFormat = NewStatusError(ErrInvalidAddressCode, codes.InvalidArgument, "The address format is invalid", "地址格式不合法")
+	ErrMarshallStruct       = NewStatusError(ErrMarshallStructCode, codes.Internal, "Serialization structure error", "序列化结构体错误")
+
+	ErrInsufficientBalance = NewStatusError(ErrInsufficientBalanceCode, codes.FailedPrecondition, "Insufficient balance", "余额不足")
+	ErrHeightConflict      = NewStatusError(ErrHeightConflictCode, codes.Aborted, "The block height or parent hash conflicts with the chain", "区块高度或父哈希与链上不一致")
+	ErrContractNotFound    = NewStatusError(ErrContractNotFoundCode, codes.NotFound, "Contract not found", "合约不存在")
+	ErrUnauthorized        = NewStatusError(ErrUnauthorizedCode, codes.Unauthenticated, "Unauthorized", "未授权")
+	ErrTimeout             = NewStatusError(ErrTimeoutCode, codes.DeadlineExceeded, "Request timeout", "请求超时")
+	ErrInvalidParams       = NewStatusError(ErrInvalidParamsCode, codes.InvalidArgument, "Invalid params", "参数不合法")
+	ErrMethodNotFound      = NewStatusError(ErrMethodNotFoundCode, codes.Unimplemented, "Method not found", "方法不存在")
 )
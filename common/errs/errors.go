@@ -1,6 +1,12 @@
 package errs
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wylu1037/lattice-go/common/codes"
+	"sync/atomic"
+)
 
 const (
 	// english
@@ -9,7 +15,35 @@ const (
 	zh = "zh-Hans"
 )
 
-var local = zh
+const (
+	// LanguageEn english messages
+	LanguageEn = en
+	// LanguageZhHans chinese messages, the default
+	LanguageZhHans = zh
+)
+
+// 进程级的语言设置，可以在运行中修改
+var local atomic.Value
+
+func init() {
+	local.Store(zh)
+}
+
+// SetLanguage set the language of error messages and sdk logs for the whole process, unsupported languages are ignored
+// Parameters:
+//   - language string: LanguageEn or LanguageZhHans
+func SetLanguage(language string) {
+	if language == en || language == zh {
+		local.Store(language)
+	}
+}
+
+// Language get the language of error messages and sdk logs
+// Returns:
+//   - string
+func Language() string {
+	return local.Load().(string)
+}
 
 // NewError create a custom error
 // Parameters:
@@ -20,8 +54,22 @@ var local = zh
 // Returns:
 //   - error
 func NewError(code int, enMsg, zhMsg string) error {
+	return NewStatusError(code, codes.Unknown, enMsg, zhMsg)
+}
+
+// NewStatusError create a custom error with the classification of codes.Code
+// Parameters:
+//   - code int: error code
+//   - status codes.Code: error classification
+//   - enMsg string: english error message
+//   - zhMsg string: chinese error message
+//
+// Returns:
+//   - error
+func NewStatusError(code int, status codes.Code, enMsg, zhMsg string) error {
 	return &Error{
-		Code: code,
+		Code:   code,
+		Status: status,
 		Message: map[string]string{
 			en: enMsg,
 			zh: zhMsg,
@@ -31,12 +79,46 @@ func NewError(code int, enMsg, zhMsg string) error {
 
 type Error struct {
 	Code    int               `json:"code"`
+	Status  codes.Code        `json:"status"`
 	Message map[string]string `json:"message"`
 }
 
 func (e *Error) Error() string {
-	if msg, ok := e.Message[local]; ok {
+	if msg, ok := e.Message[Language()]; ok {
 		return fmt.Sprintf("%d:%s", e.Code, msg)
 	}
 	return fmt.Sprintf("%d:%s", e.Code, e.Message[en])
 }
+
+// Is errors with the same code are considered equal, so errors.Is(err, ErrInsufficientBalance) matches
+// both the sentinel and any error created with the same code.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// Status get the codes.Code classification of the error
+// Parameters:
+//   - err error
+//
+// Returns:
+//   - codes.Code: codes.OK when err is nil, codes.Unknown when err can not be classified
+func Status(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Status != codes.Unknown {
+		return e.Status
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+	return codes.Unknown
+}
@@ -1,10 +1,28 @@
 package errs
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/codes"
 	"testing"
 )
 
 func TestError_Error(t *testing.T) {
 	fmt.Println(ErrInvalidAddressFormat.Error())
 }
+
+func TestError_Is(t *testing.T) {
+	err := fmt.Errorf("send transaction: %w", NewError(ErrInsufficientBalanceCode, "balance", "余额"))
+	assert.True(t, errors.Is(err, ErrInsufficientBalance))
+	assert.False(t, errors.Is(err, ErrHeightConflict))
+}
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, codes.OK, Status(nil))
+	assert.Equal(t, codes.FailedPrecondition, Status(fmt.Errorf("wrap: %w", ErrInsufficientBalance)))
+	assert.Equal(t, codes.DeadlineExceeded, Status(fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded)))
+	assert.Equal(t, codes.Canceled, Status(context.Canceled))
+	assert.Equal(t, codes.Unknown, Status(errors.New("unknown")))
+}
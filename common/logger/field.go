@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted 脱敏后的字段值
+const Redacted = "[REDACTED]"
+
+// Field 结构化的日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字符串字段
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int 整数字段
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Uint64 无符号整数字段
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool 布尔字段
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration 耗时字段
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Stringer 实现了 fmt.Stringer 的字段，如交易哈希
+func Stringer(key string, value fmt.Stringer) Field {
+	if value == nil {
+		return Field{Key: key, Value: nil}
+	}
+	return Field{Key: key, Value: value.String()}
+}
+
+// Any 任意类型的字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err 错误字段，key为error
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+var (
+	redactionMu      sync.RWMutex
+	redactionEnabled = true
+	// 默认脱敏的字段，不区分大小写
+	redactedKeys = map[string]struct{}{
+		"privatekey":    {},
+		"sk":            {},
+		"secretkey":     {},
+		"passphrase":    {},
+		"password":      {},
+		"secret":        {},
+		"jwt":           {},
+		"token":         {},
+		"authorization": {},
+		"filekey":       {},
+		"payload":       {},
+		"code":          {},
+		"data":          {},
+		"body":          {},
+	}
+)
+
+// AddRedactedKeys 增加需要脱敏的字段，不区分大小写
+//
+// Parameters:
+//   - keys ...string
+func AddRedactedKeys(keys ...string) {
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	for _, key := range keys {
+		redactedKeys[strings.ToLower(key)] = struct{}{}
+	}
+}
+
+// SetRedaction 开启或关闭脱敏，默认开启，只建议在本地调试时关闭
+//
+// Parameters:
+//   - enabled bool
+func SetRedaction(enabled bool) {
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	redactionEnabled = enabled
+}
+
+// 替换敏感字段的值，不修改入参
+func redact(fields []Field) []Field {
+	redactionMu.RLock()
+	defer redactionMu.RUnlock()
+	if !redactionEnabled {
+		return fields
+	}
+	var redacted []Field
+	for i, field := range fields {
+		if _, ok := redactedKeys[strings.ToLower(field.Key)]; !ok || field.Value == nil {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i].Value = Redacted
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Level 日志级别
+type Level int8
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// Logger SDK的日志接口，默认输出到 zerolog/log 的全局日志，可以通过 SetLogger 替换为 slog 或自定义实现
+type Logger interface {
+	// Enabled 是否输出该级别的日志，不输出时跳过字段的构造和脱敏
+	Enabled(level Level) bool
+
+	// Log 输出日志
+	//
+	// Parameters:
+	//   - level Level: 日志级别
+	//   - msg string: 已本地化的日志内容
+	//   - fields []Field: 已脱敏的结构化字段
+	Log(level Level, msg string, fields []Field)
+}
+
+type holder struct {
+	logger Logger
+}
+
+var current atomic.Pointer[holder]
+
+func init() {
+	current.Store(&holder{logger: globalZerologLogger{}})
+}
+
+// SetLogger 设置SDK的日志
+//
+// Parameters:
+//   - logger Logger: 为nil时不输出日志
+func SetLogger(logger Logger) {
+	if logger == nil {
+		logger = NewNopLogger()
+	}
+	current.Store(&holder{logger: logger})
+}
+
+// GetLogger 获取SDK的日志
+//
+// Returns:
+//   - Logger
+func GetLogger() Logger {
+	return current.Load().logger
+}
+
+// NewZerologLogger 适配 zerolog.Logger
+//
+// Parameters:
+//   - logger zerolog.Logger
+//
+// Returns:
+//   - Logger
+func NewZerologLogger(logger zerolog.Logger) Logger {
+	return &zerologLogger{logger: logger}
+}
+
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+func (l *zerologLogger) Enabled(level Level) bool {
+	return zerologEnabled(l.logger, level)
+}
+
+func (l *zerologLogger) Log(level Level, msg string, fields []Field) {
+	zerologLog(l.logger, level, msg, fields)
+}
+
+// 未设置日志时使用 zerolog/log 的全局日志，与之前的行为保持一致
+type globalZerologLogger struct{}
+
+func (globalZerologLogger) Enabled(level Level) bool {
+	return zerologEnabled(log.Logger, level)
+}
+
+func (globalZerologLogger) Log(level Level, msg string, fields []Field) {
+	zerologLog(log.Logger, level, msg, fields)
+}
+
+func zerologLevel(level Level) zerolog.Level {
+	switch level {
+	case LevelDebug:
+		return zerolog.DebugLevel
+	case LevelInfo:
+		return zerolog.InfoLevel
+	case LevelWarn:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+func zerologEnabled(logger zerolog.Logger, level Level) bool {
+	zl := zerologLevel(level)
+	return zl >= logger.GetLevel() && zl >= zerolog.GlobalLevel()
+}
+
+func zerologLog(logger zerolog.Logger, level Level, msg string, fields []Field) {
+	event := logger.WithLevel(zerologLevel(level))
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok {
+			event = event.AnErr(field.Key, err)
+		} else {
+			event = event.Interface(field.Key, field.Value)
+		}
+	}
+	event.Msg(msg)
+}
+
+// NewSlogLogger 适配 slog.Logger
+//
+// Parameters:
+//   - logger *slog.Logger: 为nil时使用 slog.Default()
+//
+// Returns:
+//   - Logger
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func (l *slogLogger) Enabled(level Level) bool {
+	return l.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+func (l *slogLogger) Log(level Level, msg string, fields []Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	l.logger.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+// NewNopLogger 不输出任何日志
+//
+// Returns:
+//   - Logger
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Enabled(Level) bool { return false }
+
+func (nopLogger) Log(Level, string, []Field) {}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"log/slog"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	defer SetLogger(globalZerologLogger{})
+	defer errs.SetLanguage(errs.LanguageZhHans)
+
+	t.Run("zerolog with redaction", func(t *testing.T) {
+		var buf bytes.Buffer
+		SetLogger(NewZerologLogger(zerolog.New(&buf).Level(zerolog.DebugLevel)))
+		Error("Failed to send the transaction", "发送交易失败", String("chainId", "1"), String("payload", "0x01"), String("privateKey", "0xabc"), Err(errors.New("boom")))
+
+		entry := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "error", entry["level"])
+		assert.Equal(t, "发送交易失败", entry["message"])
+		assert.Equal(t, "1", entry["chainId"])
+		assert.Equal(t, Redacted, entry["payload"])
+		assert.Equal(t, Redacted, entry["privateKey"])
+		assert.Equal(t, "boom", entry["error"])
+	})
+
+	t.Run("slog in english", func(t *testing.T) {
+		var buf bytes.Buffer
+		errs.SetLanguage(errs.LanguageEn)
+		SetLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))))
+		Debug("Start sending the transaction", "开始发起交易")
+		assert.Empty(t, buf.String())
+
+		Warn("Failed to renew the lock", "续约失败", String("JWT", "token"), Uint64("height", 2))
+		entry := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.Equal(t, "Failed to renew the lock", entry["msg"])
+		assert.Equal(t, Redacted, entry["JWT"])
+		assert.Equal(t, float64(2), entry["height"])
+	})
+
+	t.Run("redaction can be extended and disabled", func(t *testing.T) {
+		AddRedactedKeys("mnemonic")
+		fields := []Field{String("mnemonic", "word"), String("code", "0x60")}
+		assert.Equal(t, []Field{String("mnemonic", Redacted), String("code", Redacted)}, redact(fields))
+		assert.Equal(t, "word", fields[0].Value)
+
+		SetRedaction(false)
+		defer SetRedaction(true)
+		assert.Equal(t, fields, redact(fields))
+	})
+}
+
+func TestHelper(t *testing.T) {
+	defer SetLogger(globalZerologLogger{})
+	var global, local bytes.Buffer
+	SetLogger(NewZerologLogger(zerolog.New(&global)))
+
+	helper := NewHelper(NewZerologLogger(zerolog.New(&local)))
+	helper.Error("Failed to send the transaction", "发送交易失败", String("chainId", "1"))
+	assert.Contains(t, local.String(), "发送交易失败")
+	assert.Empty(t, global.String())
+
+	// nil helpers and helpers without a logger fall back to the process-wide logger
+	var nilHelper *Helper
+	nilHelper.Warn("Failed to renew the lock", "续约失败")
+	NewHelper(nil).Warn("Failed to renew the lock", "续约失败")
+	assert.Equal(t, 2, bytes.Count(global.Bytes(), []byte("续约失败")))
+
+	ctx := NewContext(context.Background(), helper)
+	assert.Same(t, helper, FromContext(ctx))
+	assert.Nil(t, FromContext(context.Background()))
+}
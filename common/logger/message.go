@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"context"
+	"github.com/wylu1037/lattice-go/common/errs"
+)
+
+// Debug 输出调试日志，日志内容按 errs.Language 选择语言，敏感字段会被脱敏
+//
+// Parameters:
+//   - enMsg string: 英文日志
+//   - zhMsg string: 中文日志
+//   - fields ...Field: 结构化字段
+func Debug(enMsg, zhMsg string, fields ...Field) {
+	output(LevelDebug, enMsg, zhMsg, fields)
+}
+
+// Info 输出信息日志，参数同 Debug
+func Info(enMsg, zhMsg string, fields ...Field) {
+	output(LevelInfo, enMsg, zhMsg, fields)
+}
+
+// Warn 输出警告日志，参数同 Debug
+func Warn(enMsg, zhMsg string, fields ...Field) {
+	output(LevelWarn, enMsg, zhMsg, fields)
+}
+
+// Error 输出错误日志，参数同 Debug
+func Error(enMsg, zhMsg string, fields ...Field) {
+	output(LevelError, enMsg, zhMsg, fields)
+}
+
+// Helper 输出到指定 Logger 的日志，用于按实例注入日志，nil 或未指定 Logger 时输出到 SetLogger 设置的进程级日志
+type Helper struct {
+	logger Logger
+}
+
+// NewHelper 创建输出到指定 Logger 的日志
+//
+// Parameters:
+//   - logger Logger: 为nil时输出到 SetLogger 设置的进程级日志
+//
+// Returns:
+//   - *Helper
+func NewHelper(logger Logger) *Helper {
+	return &Helper{logger: logger}
+}
+
+// Logger 获取实际输出的日志
+func (h *Helper) Logger() Logger {
+	if h == nil || h.logger == nil {
+		return GetLogger()
+	}
+	return h.logger
+}
+
+// Debug 输出调试日志，参数同 Debug
+func (h *Helper) Debug(enMsg, zhMsg string, fields ...Field) {
+	outputTo(h.Logger(), LevelDebug, enMsg, zhMsg, fields)
+}
+
+// Info 输出信息日志，参数同 Debug
+func (h *Helper) Info(enMsg, zhMsg string, fields ...Field) {
+	outputTo(h.Logger(), LevelInfo, enMsg, zhMsg, fields)
+}
+
+// Warn 输出警告日志，参数同 Debug
+func (h *Helper) Warn(enMsg, zhMsg string, fields ...Field) {
+	outputTo(h.Logger(), LevelWarn, enMsg, zhMsg, fields)
+}
+
+// Error 输出错误日志，参数同 Debug
+func (h *Helper) Error(enMsg, zhMsg string, fields ...Field) {
+	outputTo(h.Logger(), LevelError, enMsg, zhMsg, fields)
+}
+
+type contextKey struct{}
+
+// NewContext 将日志绑定到ctx，不属于某个实例的函数通过 FromContext 获取
+//
+// Parameters:
+//   - ctx context.Context
+//   - helper *Helper
+//
+// Returns:
+//   - context.Context
+func NewContext(ctx context.Context, helper *Helper) context.Context {
+	return context.WithValue(ctx, contextKey{}, helper)
+}
+
+// FromContext 获取ctx绑定的日志，未绑定时返回输出到进程级日志的 Helper
+//
+// Parameters:
+//   - ctx context.Context
+//
+// Returns:
+//   - *Helper
+func FromContext(ctx context.Context) *Helper {
+	helper, _ := ctx.Value(contextKey{}).(*Helper)
+	return helper
+}
+
+func output(level Level, enMsg, zhMsg string, fields []Field) {
+	outputTo(GetLogger(), level, enMsg, zhMsg, fields)
+}
+
+func outputTo(logger Logger, level Level, enMsg, zhMsg string, fields []Field) {
+	if !logger.Enabled(level) {
+		return
+	}
+	msg := zhMsg
+	if errs.Language() == errs.LanguageEn || msg == "" {
+		msg = enMsg
+	}
+	logger.Log(level, msg, redact(fields))
+}
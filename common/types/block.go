@@ -82,6 +82,7 @@ type TransactionBlock struct {
 	CodeHash   string      `json:"codeHash"`
 	Amount     string      `json:"amount"`
 	Joule      uint64      `json:"joule"`
+	Difficulty uint64      `json:"difficulty"`
 	Sign       string      `json:"sign"`
 	Pow        string      `json:"proofOfWork"`
 	Size       uint64      `json:"size"`
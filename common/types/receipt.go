@@ -2,7 +2,7 @@ package types
 
 import (
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/rs/zerolog/log"
+	"github.com/wylu1037/lattice-go/common/logger"
 	"strconv"
 )
 
@@ -26,7 +26,7 @@ func (r *Receipt) GetConfirmedTimestamp() int64 {
 	}
 	timestamp, err := strconv.ParseInt(r.ConfirmedTimestamp, 10, 64)
 	if err != nil {
-		log.Error().Err(err).Msgf("解析回执的ConfirmedTimestamp的值%s为int64失败", r.ConfirmedTimestamp)
+		logger.Error("Failed to parse the ConfirmedTimestamp of the receipt", "解析回执的ConfirmedTimestamp为int64失败", logger.String("confirmedTimestamp", r.ConfirmedTimestamp), logger.Err(err))
 		return 0
 	}
 	return timestamp
@@ -42,3 +42,11 @@ type Event struct {
 	Removed      bool          `json:"removed"`
 	DataHex      string        `json:"dataHex"`
 }
+
+// EventFilter 合约事件的过滤条件
+//   - Addresses 合约地址，为空时不过滤合约地址
+//   - Topics    事件主题，按位置匹配，每个位置可以指定多个候选主题，为空时不过滤该位置
+type EventFilter struct {
+	Addresses []string        `json:"address,omitempty"`
+	Topics    [][]common.Hash `json:"topics,omitempty"`
+}
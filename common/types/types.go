@@ -27,7 +27,7 @@ func (n Number) MustToBigInt() *big.Int {
 
 // UploadFileResponse 文件上传到链上的返回结果
 //
-//   - CID 文件唯一标识，示例：GPK3PveRaWoK6S2b53D3ZeJTm4nBvv2vSVjRStRLQcyX
+//   - CID 文件唯一标识，为文件内容哈希(32字节)的base58编码，示例：GPK3PveRaWoK6S2b53D3ZeJTm4nBvv2vSVjRStRLQcyX
 //   - FilePath 文件存储地址，示例：JG-DFS/tempFileDir/20240816/1723793768943848748_avatar.svg"
 //   - Message 返回信息，示例：success
 //   - OccupiedStorageByte 文件占用的存储字节数，单位为byte，示例：255686
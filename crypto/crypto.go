@@ -3,29 +3,100 @@ package crypto
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/crypto/secp256k1"
 	"github.com/wylu1037/lattice-go/crypto/sm2p256v1"
 	"io"
+	"sort"
+	"sync"
 )
 
-var instance CryptographyApi
+var (
+	// ErrUnsupportedCurve 曲线未注册
+	ErrUnsupportedCurve = errors.New("unsupported curve")
+	// ErrCurveRegistered 曲线已经注册过
+	ErrCurveRegistered = errors.New("the curve has already been registered")
+	// ErrSignatureMismatch 签名与哈希不匹配，Sm2p256v1 无法从中恢复出公钥
+	ErrSignatureMismatch = sm2p256v1.ErrSignatureMismatch
+)
 
-func NewCrypto(curve types.Curve) CryptographyApi {
-	if instance != nil {
-		return instance
+var (
+	registryMu sync.RWMutex
+	registry   = map[types.Curve]CryptographyApi{
+		Sm2p256v1: sm2p256v1.New(),
+		Secp256k1: secp256k1.New(),
+	}
+)
+
+// RegisterCrypto 注册曲线的实现，每条曲线只能注册一次，注册后不可替换
+//
+// Parameters:
+//   - curve types.Curve
+//   - api CryptographyApi
+//
+// Returns:
+//   - error: 曲线已注册时为 ErrCurveRegistered
+func RegisterCrypto(curve types.Curve, api CryptographyApi) error {
+	if curve == "" || api == nil {
+		return errors.New("the curve and its implementation can not be empty")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[curve]; ok {
+		return fmt.Errorf("%w: %s", ErrCurveRegistered, curve)
+	}
+	registry[curve] = api
+	return nil
+}
+
+// GetCrypto 获取曲线的实现，不同曲线的实例相互独立，可以在同一个进程中同时使用
+//
+// Parameters:
+//   - curve types.Curve
+//
+// Returns:
+//   - CryptographyApi
+//   - error: 曲线未注册时为 ErrUnsupportedCurve
+func GetCrypto(curve types.Curve) (CryptographyApi, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if api, ok := registry[curve]; ok {
+		return api, nil
 	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, curve)
+}
 
-	switch curve {
-	case Sm2p256v1:
-		instance = sm2p256v1.New()
-	case Secp256k1:
-		instance = secp256k1.New()
-	default:
-		instance = sm2p256v1.New()
+// Curves 获取已注册的曲线
+//
+// Returns:
+//   - []types.Curve
+func Curves() []types.Curve {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	curves := make([]types.Curve, 0, len(registry))
+	for curve := range registry {
+		curves = append(curves, curve)
+	}
+	sort.Slice(curves, func(i, j int) bool { return curves[i] < curves[j] })
+	return curves
+}
+
+// NewCrypto 获取曲线的实现，曲线未注册时panic，曲线来自外部输入时使用 GetCrypto
+//
+// Parameters:
+//   - curve types.Curve
+//
+// Returns:
+//   - CryptographyApi
+func NewCrypto(curve types.Curve) CryptographyApi {
+	api, err := GetCrypto(curve)
+	if err != nil {
+		panic(err)
 	}
-	return instance
+	return api
 }
 
 type CryptographyApi interface {
@@ -52,6 +123,8 @@ type CryptographyApi interface {
 	Sign(hash []byte, sk *ecdsa.PrivateKey) (signature []byte, err error)
 	// SignatureToPK 从签名恢复公钥
 	SignatureToPK(hash, signature []byte) (*ecdsa.PublicKey, error)
+	// SignatureToAddress 从签名恢复签名者的地址
+	SignatureToAddress(hash, signature []byte) (common.Address, error)
 	// Verify 验证签名
 	Verify(hash []byte, signature []byte, pk *ecdsa.PublicKey) bool
 	// CompressPK 压缩公钥
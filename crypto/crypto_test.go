@@ -1,9 +1,12 @@
 package crypto
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
 	"testing"
+	"time"
 )
 
 func TestNewCrypto(t *testing.T) {
@@ -29,3 +32,45 @@ func TestGenerateAddressFromPrivateKey(t *testing.T) {
 	expect := "zltc_jF4U7umzNpiE8uU35RCBp9f2qf53H5CZZ"
 	assert.Equal(t, zltc, expect)
 }
+
+func TestGetCrypto(t *testing.T) {
+	gm, err := GetCrypto(Sm2p256v1)
+	assert.NoError(t, err)
+	nonGM, err := GetCrypto(Secp256k1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, gm.GetCurve().Params().Name, nonGM.GetCurve().Params().Name)
+
+	// 同一进程中交替使用两条曲线
+	hash := gm.Hash([]byte("Hello World")).Bytes()
+	for _, api := range []CryptographyApi{gm, nonGM, gm} {
+		sk, err := api.GenerateKeyPair()
+		assert.NoError(t, err)
+		signature, err := api.Sign(hash, sk)
+		assert.NoError(t, err)
+		assert.True(t, api.Verify(hash, signature, &sk.PublicKey))
+	}
+	assert.Equal(t, nonGM, NewCrypto(Secp256k1))
+	assert.Equal(t, gm, NewCrypto(Sm2p256v1))
+
+	_, err = GetCrypto("p256")
+	assert.ErrorIs(t, err, ErrUnsupportedCurve)
+	assert.Panics(t, func() { NewCrypto("p256") })
+	assert.ErrorIs(t, RegisterCrypto(Secp256k1, gm), ErrCurveRegistered)
+
+	// 注册表是全局的，使用唯一的曲线名并在结束后注销
+	curve := types.Curve(fmt.Sprintf("sm2-alias-%d", time.Now().UnixNano()))
+	assert.NoError(t, RegisterCrypto(curve, gm))
+	t.Cleanup(func() { unregisterCrypto(curve) })
+	alias, err := GetCrypto(curve)
+	assert.NoError(t, err)
+	assert.Equal(t, gm, alias)
+	assert.Contains(t, Curves(), curve)
+	assert.ErrorIs(t, RegisterCrypto(curve, gm), ErrCurveRegistered)
+}
+
+// 注销测试中注册的曲线
+func unregisterCrypto(curve types.Curve) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, curve)
+}
@@ -0,0 +1,193 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"strconv"
+)
+
+// DefaultMessageDomain 消息签名默认的域，签名的内容为 "\x19Lattice Signed Message:\n" + len(message) + message
+const DefaultMessageDomain = "Lattice"
+
+// ErrMessageSignerMismatch 消息的签名者与期望的地址不一致
+var ErrMessageSignerMismatch = errors.New("the message was not signed by the expected address")
+
+// uidSigner 支持用户ID的签名算法，如SM2，对消息本身而不是消息的哈希签名
+type uidSigner interface {
+	SignWithUID(msg, uid []byte, sk *ecdsa.PrivateKey) ([]byte, error)
+	SignatureToPKWithUID(msg, uid, signature []byte) (*ecdsa.PublicKey, error)
+}
+
+// MessageOption 消息签名的可选项，签名和验签时需要使用相同的选项
+type MessageOption func(*messageOptions)
+
+type messageOptions struct {
+	domain string
+	uid    []byte
+}
+
+func newMessageOptions(opts []MessageOption) *messageOptions {
+	options := &messageOptions{domain: DefaultMessageDomain}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithMessageDomain 设置消息签名的域，不同的应用使用不同的域，防止签名被挪用到其它场景，
+// 如登录使用 "Lattice Login"，API请求使用 "Lattice API"
+func WithMessageDomain(domain string) MessageOption {
+	return func(options *messageOptions) {
+		options.domain = domain
+	}
+}
+
+// WithSM2UserId 设置SM2签名的用户ID，参与 Z_A 的计算，默认为 1234567812345678，对 Secp256k1 无效
+func WithSM2UserId(uid []byte) MessageOption {
+	return func(options *messageOptions) {
+		options.uid = uid
+	}
+}
+
+// PrefixMessage 为消息加上域前缀，"\x19" + domain + " Signed Message:\n" + len(message) + message，
+// 带前缀的消息不可能是合法的交易编码，因此消息签名无法被当作交易签名使用
+//
+// Parameters:
+//   - domain string: 域
+//   - message []byte: 消息
+//
+// Returns:
+//   - []byte
+func PrefixMessage(domain string, message []byte) []byte {
+	prefixed := make([]byte, 0, len(domain)+len(message)+32)
+	prefixed = append(prefixed, 0x19)
+	prefixed = append(prefixed, domain...)
+	prefixed = append(prefixed, " Signed Message:\n"...)
+	prefixed = strconv.AppendInt(prefixed, int64(len(message)), 10)
+	return append(prefixed, message...)
+}
+
+// SignMessage 对任意的链下消息签名，用于钱包登录、API请求签名等场景。
+// Secp256k1 对带前缀消息的哈希签名，Sm2p256v1 使用用户ID直接对带前缀的消息签名
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//   - sk *ecdsa.PrivateKey: 私钥
+//   - message []byte: 消息
+//   - opts ...MessageOption
+//
+// Returns:
+//   - []byte: 签名，格式与 CryptographyApi.Sign 一致
+//   - error
+func SignMessage(curve types.Curve, sk *ecdsa.PrivateKey, message []byte, opts ...MessageOption) ([]byte, error) {
+	api, err := GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
+	options := newMessageOptions(opts)
+	prefixed := PrefixMessage(options.domain, message)
+	if signer, ok := api.(uidSigner); ok {
+		return signer.SignWithUID(prefixed, options.uid, sk)
+	}
+	return api.Sign(api.Hash(prefixed).Bytes(), sk)
+}
+
+// SignMessageWithSigner 使用签名者对链下消息签名，私钥保存在HSM、KMS或远程签名服务中时使用，签名与 SignMessage 一致。
+// Sm2p256v1 需要签名者实现 UIDSigner
+//
+// Parameters:
+//   - signer Signer: 签名者
+//   - message []byte: 消息
+//   - opts ...MessageOption
+//
+// Returns:
+//   - []byte: 签名
+//   - error: Sm2p256v1 的签名者未实现 UIDSigner 时为 ErrUIDSigningUnsupported
+func SignMessageWithSigner(signer Signer, message []byte, opts ...MessageOption) ([]byte, error) {
+	api, err := GetCrypto(signer.Curve())
+	if err != nil {
+		return nil, err
+	}
+	options := newMessageOptions(opts)
+	prefixed := PrefixMessage(options.domain, message)
+	if _, ok := api.(uidSigner); ok {
+		withUID, ok := signer.(UIDSigner)
+		if !ok {
+			return nil, ErrUIDSigningUnsupported
+		}
+		return withUID.SignWithUID(prefixed, options.uid)
+	}
+	return signer.Sign(api.Hash(prefixed).Bytes())
+}
+
+// VerifyMessage 验证 SignMessage 生成的签名，并恢复签名者的地址
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//   - message []byte: 消息
+//   - signature []byte: 签名
+//   - opts ...MessageOption: 需要与签名时一致
+//
+// Returns:
+//   - string: 签名者的zltc地址
+//   - error: 签名无法解析或与消息不匹配时返回
+func VerifyMessage(curve types.Curve, message, signature []byte, opts ...MessageOption) (string, error) {
+	api, err := GetCrypto(curve)
+	if err != nil {
+		return "", err
+	}
+	options := newMessageOptions(opts)
+	prefixed := PrefixMessage(options.domain, message)
+	if signer, ok := api.(uidSigner); ok {
+		pk, err := signer.SignatureToPKWithUID(prefixed, options.uid, signature)
+		if err != nil {
+			return "", err
+		}
+		address, err := api.PKToAddress(pk)
+		if err != nil {
+			return "", err
+		}
+		return convert.AddressToZltc(address), nil
+	}
+
+	hash := api.Hash(prefixed).Bytes()
+	pk, err := api.SignatureToPK(hash, signature)
+	if err != nil {
+		return "", err
+	}
+	if !api.Verify(hash, signature, pk) {
+		return "", ErrSignatureMismatch
+	}
+	address, err := api.PKToAddress(pk)
+	if err != nil {
+		return "", err
+	}
+	return convert.AddressToZltc(address), nil
+}
+
+// VerifyMessageFrom 验证消息是否由指定的地址签名
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//   - message []byte: 消息
+//   - signature []byte: 签名
+//   - zltc string: 期望的签名者地址
+//   - opts ...MessageOption: 需要与签名时一致
+//
+// Returns:
+//   - error: 签名者不是该地址时为 ErrMessageSignerMismatch
+func VerifyMessageFrom(curve types.Curve, message, signature []byte, zltc string, opts ...MessageOption) error {
+	signer, err := VerifyMessage(curve, message, signature, opts...)
+	if errors.Is(err, ErrSignatureMismatch) {
+		return ErrMessageSignerMismatch
+	}
+	if err != nil {
+		return err
+	}
+	if signer != zltc {
+		return ErrMessageSignerMismatch
+	}
+	return nil
+}
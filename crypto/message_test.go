@@ -0,0 +1,83 @@
+package crypto
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	message := []byte("login at 2024-06-01T00:00:00Z, nonce 1")
+	for _, curve := range []types.Curve{Secp256k1, Sm2p256v1} {
+		api := NewCrypto(curve)
+		sk, err := api.GenerateKeyPair()
+		assert.NoError(t, err)
+		address, err := api.PKToAddress(&sk.PublicKey)
+		assert.NoError(t, err)
+		zltc := convert.AddressToZltc(address)
+
+		signature, err := SignMessage(curve, sk, message)
+		assert.NoError(t, err)
+		signer, err := VerifyMessage(curve, message, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, zltc, signer)
+		assert.NoError(t, VerifyMessageFrom(curve, message, signature, zltc))
+
+		// 消息被篡改
+		assert.ErrorIs(t, VerifyMessageFrom(curve, []byte("login at 2024-06-01T00:00:00Z, nonce 2"), signature, zltc), ErrMessageSignerMismatch)
+		// 其它域的签名
+		assert.ErrorIs(t, VerifyMessageFrom(curve, message, signature, zltc, WithMessageDomain("Lattice API")), ErrMessageSignerMismatch)
+
+		// 不能当作对哈希的签名使用
+		assert.False(t, api.Verify(api.Hash(message).Bytes(), signature, &sk.PublicKey))
+	}
+
+	// SM2的用户ID
+	sk, err := NewCrypto(Sm2p256v1).GenerateKeyPair()
+	assert.NoError(t, err)
+	signature, err := SignMessage(Sm2p256v1, sk, message, WithSM2UserId([]byte("alice@lattice")))
+	assert.NoError(t, err)
+	_, err = VerifyMessage(Sm2p256v1, message, signature)
+	assert.ErrorIs(t, err, ErrSignatureMismatch)
+	signer, err := VerifyMessage(Sm2p256v1, message, signature, WithSM2UserId([]byte("alice@lattice")))
+	assert.NoError(t, err)
+	address, _ := NewCrypto(Sm2p256v1).PKToAddress(&sk.PublicKey)
+	assert.Equal(t, convert.AddressToZltc(address), signer)
+
+	assert.Equal(t, []byte("\x19Lattice Signed Message:\n5hello"), PrefixMessage(DefaultMessageDomain, []byte("hello")))
+}
+
+// 只实现了 Signer 的签名者
+type hashOnlySigner struct {
+	Signer
+}
+
+func TestSignMessageWithSigner(t *testing.T) {
+	message := []byte("login at 2024-06-01T00:00:00Z, nonce 1")
+	for _, curve := range []types.Curve{Secp256k1, Sm2p256v1} {
+		sk, err := NewCrypto(curve).GenerateKeyPair()
+		assert.NoError(t, err)
+		signer, err := NewMemorySigner(curve, sk)
+		assert.NoError(t, err)
+		zltc := convert.AddressToZltc(signer.Address())
+
+		signature, err := SignMessageWithSigner(signer, message, WithSM2UserId([]byte("alice@lattice")))
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyMessageFrom(curve, message, signature, zltc, WithSM2UserId([]byte("alice@lattice"))))
+
+		_, err = SignMessageWithSigner(hashOnlySigner{signer}, message)
+		if curve == Sm2p256v1 {
+			assert.ErrorIs(t, err, ErrUIDSigningUnsupported)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	sk, err := NewCrypto(Secp256k1).GenerateKeyPair()
+	assert.NoError(t, err)
+	signer, err := NewMemorySigner(Secp256k1, sk)
+	assert.NoError(t, err)
+	_, err = signer.(UIDSigner).SignWithUID(message, nil)
+	assert.ErrorIs(t, err, ErrUIDSigningUnsupported)
+}
@@ -144,6 +144,15 @@ func (i *NistApi) SignatureToPK(hash, signature []byte) (*ecdsa.PublicKey, error
 	return i.BytesToPK(pkBytes)
 }
 
+// SignatureToAddress 从签名恢复签名者的地址
+func (i *NistApi) SignatureToAddress(hash, signature []byte) (common.Address, error) {
+	pk, err := i.SignatureToPK(hash, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return i.PKToAddress(pk)
+}
+
 // Verify 验证签名
 func (i *NistApi) Verify(hash []byte, signature []byte, pk *ecdsa.PublicKey) bool {
 	pkBytes, err := i.PKToBytes(pk)
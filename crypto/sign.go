@@ -1 +1,241 @@
 package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wylu1037/lattice-go/common/types"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultRemoteSignerTimeout = 10 * time.Second
+
+var (
+	// ErrSignerAddressMismatch 签名恢复出的地址与签名者的地址不一致，或无法从签名恢复地址
+	ErrSignerAddressMismatch = errors.New("the signature does not belong to the signer address")
+	// ErrUIDSigningUnsupported 签名者不支持使用用户ID对消息签名
+	ErrUIDSigningUnsupported = errors.New("the signer does not support signing with a user id")
+)
+
+// Signer 签名者，私钥可以保存在内存、HSM、KMS或远程签名服务中，调用方只能拿到签名结果
+type Signer interface {
+	// Address 签名者的地址
+	Address() common.Address
+
+	// Curve 签名使用的椭圆曲线
+	Curve() types.Curve
+
+	// Sign 对32字节的哈希签名，签名格式与 CryptographyApi.Sign 一致
+	//
+	// Parameters:
+	//   - hash []byte
+	//
+	// Returns:
+	//   - []byte: 签名
+	//   - error
+	Sign(hash []byte) ([]byte, error)
+}
+
+// UIDSigner 支持使用用户ID对消息本身签名的签名者，Sm2p256v1 的消息签名需要签名者实现该接口
+type UIDSigner interface {
+	Signer
+
+	// SignWithUID 使用用户ID对消息签名，签名格式与 Signer.Sign 一致
+	//
+	// Parameters:
+	//   - msg []byte: 消息
+	//   - uid []byte: 用户ID，为nil时使用默认的 1234567812345678
+	//
+	// Returns:
+	//   - []byte: 签名
+	//   - error: 曲线不支持用户ID时为 ErrUIDSigningUnsupported
+	SignWithUID(msg, uid []byte) ([]byte, error)
+}
+
+// NewMemorySigner 初始化使用内存中私钥的签名者
+//
+// Parameters:
+//   - curve types.Curve
+//   - sk *ecdsa.PrivateKey
+//
+// Returns:
+//   - Signer
+//   - error
+func NewMemorySigner(curve types.Curve, sk *ecdsa.PrivateKey) (Signer, error) {
+	api, err := GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
+	address, err := api.PKToAddress(&sk.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &memorySigner{curve: curve, api: api, sk: sk, address: address}, nil
+}
+
+// NewMemorySignerFromHex 初始化使用内存中私钥的签名者，私钥解析后不再保留hex字符串
+//
+// Parameters:
+//   - curve types.Curve
+//   - skHex string: 私钥
+//
+// Returns:
+//   - Signer
+//   - error
+func NewMemorySignerFromHex(curve types.Curve, skHex string) (Signer, error) {
+	api, err := GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := api.HexToSK(skHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewMemorySigner(curve, sk)
+}
+
+type memorySigner struct {
+	curve   types.Curve
+	api     CryptographyApi
+	sk      *ecdsa.PrivateKey
+	address common.Address
+}
+
+func (s *memorySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *memorySigner) Curve() types.Curve {
+	return s.curve
+}
+
+func (s *memorySigner) Sign(hash []byte) ([]byte, error) {
+	return s.api.Sign(hash, s.sk)
+}
+
+func (s *memorySigner) SignWithUID(msg, uid []byte) ([]byte, error) {
+	api, ok := s.api.(uidSigner)
+	if !ok {
+		return nil, ErrUIDSigningUnsupported
+	}
+	return api.SignWithUID(msg, uid, s.sk)
+}
+
+// RemoteSignerConfig 远程签名服务的配置
+//
+// 签名请求为 POST Url，body为 {"address":"0x...","curve":"...","hash":"0x..."}，
+// 响应为 {"signature":"0x..."}，配置了Token时通过 Authorization: Bearer 头传递，
+// 返回的签名恢复出的地址必须与Address一致，曲线未注册或签名无法恢复公钥时签名失败
+type RemoteSignerConfig struct {
+	Url       string            // 签名服务的地址
+	Address   common.Address    // 签名账户的地址
+	Curve     types.Curve       // 椭圆曲线
+	Token     string            // 访问签名服务的token
+	Timeout   time.Duration     // 单次签名的超时时间，默认10s
+	Transport http.RoundTripper // 为nil时使用 http.DefaultTransport
+}
+
+// NewRemoteSigner 初始化通过http调用远程签名服务的签名者
+//
+// Parameters:
+//   - config *RemoteSignerConfig
+//
+// Returns:
+//   - Signer
+func NewRemoteSigner(config *RemoteSignerConfig) Signer {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteSignerTimeout
+	}
+	transport := config.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &remoteSigner{
+		config: config,
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}
+}
+
+type remoteSigner struct {
+	config *RemoteSignerConfig
+	client *http.Client
+}
+
+type remoteSignRequest struct {
+	Address common.Address `json:"address"`
+	Curve   types.Curve    `json:"curve"`
+	Hash    hexutil.Bytes  `json:"hash"`
+}
+
+type remoteSignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.config.Address
+}
+
+func (s *remoteSigner) Curve() types.Curve {
+	return s.config.Curve
+}
+
+func (s *remoteSigner) Sign(hash []byte) ([]byte, error) {
+	body, err := json.Marshal(&remoteSignRequest{Address: s.config.Address, Curve: s.config.Curve, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.config.Token != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.config.Token))
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer responded with status %d: %s", response.StatusCode, responseBody)
+	}
+	result := new(remoteSignResponse)
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return nil, err
+	}
+
+	// 从签名恢复公钥，确认签名属于该地址，无法确认时不返回签名
+	api, err := GetCrypto(s.config.Curve)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := api.SignatureToPK(hash, result.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignerAddressMismatch, err)
+	}
+	if pk == nil {
+		return nil, ErrSignerAddressMismatch
+	}
+	address, err := api.PKToAddress(pk)
+	if err != nil {
+		return nil, err
+	}
+	if address != s.config.Address {
+		return nil, ErrSignerAddressMismatch
+	}
+	return result.Signature, nil
+}
@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSigner_Sign(t *testing.T) {
+	api := NewCrypto(Sm2p256v1)
+	sk, err := api.GenerateKeyPair()
+	assert.NoError(t, err)
+	// the key only lives in the signing service
+	memorySigner, err := NewMemorySigner(Sm2p256v1, sk)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		request := new(remoteSignRequest)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		assert.Equal(t, memorySigner.Address(), request.Address)
+		signature, err := memorySigner.Sign(request.Hash)
+		assert.NoError(t, err)
+		assert.NoError(t, json.NewEncoder(w).Encode(&remoteSignResponse{Signature: signature}))
+	}))
+	defer server.Close()
+
+	hash := api.Hash([]byte("lattice"))
+	signer := NewRemoteSigner(&RemoteSignerConfig{Url: server.URL, Address: memorySigner.Address(), Curve: Sm2p256v1, Token: "secret"})
+	signature, err := signer.Sign(hash.Bytes())
+	assert.NoError(t, err)
+	assert.True(t, api.Verify(hash.Bytes(), signature, &sk.PublicKey))
+
+	_, err = NewRemoteSigner(&RemoteSignerConfig{Url: server.URL, Address: memorySigner.Address(), Curve: Sm2p256v1}).Sign(hash.Bytes())
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestRemoteSigner_Sign_Unverifiable(t *testing.T) {
+	api := NewCrypto(Sm2p256v1)
+	sk, err := api.GenerateKeyPair()
+	assert.NoError(t, err)
+	memorySigner, err := NewMemorySigner(Sm2p256v1, sk)
+	assert.NoError(t, err)
+	other, err := api.GenerateKeyPair()
+	assert.NoError(t, err)
+	otherSigner, err := NewMemorySigner(Sm2p256v1, other)
+	assert.NoError(t, err)
+
+	var signature []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(&remoteSignResponse{Signature: signature}))
+	}))
+	defer server.Close()
+
+	hash := api.Hash([]byte("lattice"))
+	signer := NewRemoteSigner(&RemoteSignerConfig{Url: server.URL, Address: memorySigner.Address(), Curve: Sm2p256v1})
+	// signed by another key
+	signature, err = otherSigner.Sign(hash.Bytes())
+	assert.NoError(t, err)
+	_, err = signer.Sign(hash.Bytes())
+	assert.ErrorIs(t, err, ErrSignerAddressMismatch)
+
+	// the public key cannot be recovered from a malformed signature
+	signature = []byte{0x01, 0x02}
+	_, err = signer.Sign(hash.Bytes())
+	assert.ErrorIs(t, err, ErrSignerAddressMismatch)
+
+	// the curve is not registered
+	signature, err = memorySigner.Sign(hash.Bytes())
+	assert.NoError(t, err)
+	_, err = NewRemoteSigner(&RemoteSignerConfig{Url: server.URL, Address: memorySigner.Address(), Curve: "unknown"}).Sign(hash.Bytes())
+	assert.Error(t, err)
+}
@@ -17,6 +17,9 @@ import (
 	"math/big"
 )
 
+// ErrSignatureMismatch 签名不是对该哈希的签名，无法恢复公钥
+var ErrSignatureMismatch = errors.New("the signature does not match the hash")
+
 var (
 	secp256k1N, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
 	secp256k1halfN = new(big.Int).Div(secp256k1N, big.NewInt(2))
@@ -161,10 +164,23 @@ func (i *GmApi) Sign(hash []byte, sk *ecdsa.PrivateKey) (signature []byte, err e
 	if len(hash) != constant.HashLength {
 		return nil, fmt.Errorf("hash is required to be exactly 32 bytes (%d)", len(hash))
 	}
+	// use default uid: []byte{0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38}
+	return i.SignWithUID(hash, nil, sk)
+}
 
+// SignWithUID 使用用户ID签名任意长度的消息，e = SM3(Z_A||msg)，Z_A 由用户ID和公钥计算
+//
+// Parameters:
+//   - msg []byte: 消息
+//   - uid []byte: 用户ID，为nil时使用默认的 1234567812345678
+//   - sk *ecdsa.PrivateKey
+//
+// Returns:
+//   - []byte: r||s||remark||e 共97个字节的签名
+//   - error
+func (i *GmApi) SignWithUID(msg, uid []byte, sk *ecdsa.PrivateKey) (signature []byte, err error) {
 	privateKey := convert.EcdsaSKToSm2SK(sk)
-	// use default uid: []byte{0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38}
-	r, s, err := sm2.Sm2Sign(privateKey, hash, nil, rand.Reader)
+	r, s, err := sm2.Sm2Sign(privateKey, msg, uid, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
@@ -174,12 +190,8 @@ func (i *GmApi) Sign(hash []byte, sk *ecdsa.PrivateKey) (signature []byte, err e
 	copy(signature[64-len(s.Bytes()):], s.Bytes())
 	signature[64] = constant.Sm2p256v1SignatureRemark
 
-	if len(signature) != 65 {
-		return nil, errors.New(fmt.Sprintf("sig length is wrong !!! sig length is %d ", len(signature)))
-	}
-
 	// calculate E
-	digest, err := privateKey.PublicKey.Sm3Digest(hash, nil)
+	digest, err := privateKey.PublicKey.Sm3Digest(msg, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -193,12 +205,104 @@ func (i *GmApi) Sign(hash []byte, sk *ecdsa.PrivateKey) (signature []byte, err e
 }
 
 // SignatureToPK 从签名恢复公钥
+//
+// 签名为 r||s||remark||e 共97个字节，由 r = (e + x1) mod n 得到 kG 的横坐标 x1，
+// 公钥 P = (s + r)^-1 * (kG - sG)，kG 的纵坐标有两种可能，
+// 由于 e = SM3(Z_A||M) 中的 Z_A 与公钥相关，重新计算 e 与签名中的 e 一致的候选公钥即为签名者的公钥
+//
+// Parameters:
+//   - hash []byte: 签名的哈希
+//   - signature []byte: Sign 生成的97个字节的签名
+//
+// Returns:
+//   - *ecdsa.PublicKey
+//   - error: 签名与哈希不匹配时为 ErrSignatureMismatch
 func (i *GmApi) SignatureToPK(hash, signature []byte) (*ecdsa.PublicKey, error) {
+	return i.SignatureToPKWithUID(hash, nil, signature)
+}
+
+// SignatureToPKWithUID 从 SignWithUID 生成的签名恢复公钥
+//
+// Parameters:
+//   - msg []byte: 消息
+//   - uid []byte: 签名时使用的用户ID，为nil时使用默认的 1234567812345678
+//   - signature []byte: 97个字节的签名
+//
+// Returns:
+//   - *ecdsa.PublicKey
+//   - error: 签名与消息不匹配时为 ErrSignatureMismatch
+func (i *GmApi) SignatureToPKWithUID(msg, uid, signature []byte) (*ecdsa.PublicKey, error) {
+	if len(signature) != 97 {
+		return nil, fmt.Errorf("the signature is required to be exactly 97 bytes (%d)", len(signature))
+	}
+	curve := i.GetCurve()
+	params := curve.Params()
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:64])
 	e := new(big.Int).SetBytes(signature[65:])
-	signature = signature[:65]
+	if r.Sign() <= 0 || r.Cmp(params.N) >= 0 || s.Sign() <= 0 || s.Cmp(params.N) >= 0 {
+		return nil, errors.New("invalid signature")
+	}
+	// (s + r)^-1 mod n
+	sr := new(big.Int).Add(s, r)
+	sr.Mod(sr, params.N)
+	if sr.Sign() == 0 {
+		return nil, errors.New("invalid signature")
+	}
+	srInv := new(big.Int).ModInverse(sr, params.N)
+
+	// -sG
+	sGx, sGy := curve.ScalarBaseMult(s.Bytes())
+	sGy.Sub(params.P, sGy)
+
+	x1 := new(big.Int).Sub(r, e)
+	x1.Mod(x1, params.N)
+	// x1 < p，x1 + n 也可能是合法的横坐标
+	for ; x1.Cmp(params.P) < 0; x1 = new(big.Int).Add(x1, params.N) {
+		y1 := i.decompressY(x1)
+		if y1 == nil {
+			continue
+		}
+		for _, ry := range []*big.Int{y1, new(big.Int).Sub(params.P, y1)} {
+			x, y := curve.Add(x1, ry, sGx, sGy)
+			x, y = curve.ScalarMult(x, y, srInv.Bytes())
+			if x.Sign() == 0 && y.Sign() == 0 {
+				continue
+			}
+			pk := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+			sm2PK := convert.EcdsaPKToSm2PK(pk)
+			digest, err := sm2PK.Sm3Digest(msg, uid)
+			if err != nil {
+				return nil, err
+			}
+			if new(big.Int).SetBytes(digest).Cmp(e) == 0 && sm2.Sm2Verify(sm2PK, msg, uid, r, s) {
+				return pk, nil
+			}
+		}
+	}
+	return nil, ErrSignatureMismatch
+}
+
+// SignatureToAddress 从签名恢复签名者的地址
+func (i *GmApi) SignatureToAddress(hash, signature []byte) (common.Address, error) {
+	pk, err := i.SignatureToPK(hash, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return i.PKToAddress(pk)
+}
 
-	fmt.Println(e)
-	return nil, nil
+// 根据横坐标计算曲线上的点的纵坐标 y^2 = x^3 - 3x + b，x不在曲线上时返回nil
+func (i *GmApi) decompressY(x *big.Int) *big.Int {
+	params := i.GetCurve().Params()
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	x3.Sub(x3, threeX)
+	x3.Add(x3, params.B)
+	x3.Mod(x3, params.P)
+	return new(big.Int).ModSqrt(x3, params.P)
 }
 
 // Verify 验证签名
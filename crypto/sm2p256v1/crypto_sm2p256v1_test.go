@@ -67,3 +67,39 @@ func TestSm2p256v1Api_PKToAddress(t *testing.T) {
 	t.Log("ZLTC Address:", convert.AddressToZltc(addr))
 	assert.Len(t, addr, 20)
 }
+
+func TestSm2p256v1Api_SignatureToPK(t *testing.T) {
+	crypto := New()
+	hash := crypto.Hash([]byte("Hello World")).Bytes()
+	for n := 0; n < 20; n++ {
+		sk, err := crypto.GenerateKeyPair()
+		assert.NoError(t, err)
+		signature, err := crypto.Sign(hash, sk)
+		assert.NoError(t, err)
+
+		pk, err := crypto.SignatureToPK(hash, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, sk.PublicKey.X, pk.X)
+		assert.Equal(t, sk.PublicKey.Y, pk.Y)
+
+		expect, err := crypto.PKToAddress(&sk.PublicKey)
+		assert.NoError(t, err)
+		address, err := crypto.SignatureToAddress(hash, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, address)
+	}
+
+	sk, _ := crypto.GenerateKeyPair()
+	signature, _ := crypto.Sign(hash, sk)
+	// 哈希不一致时无法恢复
+	_, err := crypto.SignatureToPK(crypto.Hash([]byte("Hello")).Bytes(), signature)
+	assert.Error(t, err)
+	// 缺少e
+	_, err = crypto.SignatureToPK(hash, signature[:65])
+	assert.Error(t, err)
+	// e被篡改
+	tampered := append([]byte{}, signature...)
+	tampered[96] ^= 0x01
+	_, err = crypto.SignatureToPK(hash, tampered)
+	assert.Error(t, err)
+}
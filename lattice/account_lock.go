@@ -1,6 +1,7 @@
 package lattice
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -29,16 +30,56 @@ type AccountLock interface {
 	Unlock(chainId, address string)
 }
 
+// ContextAccountLock 可以取消等待的账户锁，发交易时优先使用 ObtainContext，等待锁的时间计入请求的ctx
+type ContextAccountLock interface {
+	AccountLock
+
+	// ObtainContext 获取账户锁，ctx结束时放弃等待
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string: 链ID
+	//   - address string: 账户地址
+	//
+	// Returns:
+	//   - error: 未获取到锁时返回ctx的错误
+	ObtainContext(ctx context.Context, chainId, address string) error
+}
+
+// 获取账户锁，账户锁实现了 ContextAccountLock 时可以通过ctx取消等待
+func obtainAccountLock(ctx context.Context, lock AccountLock, chainId, address string) error {
+	if contextLock, ok := lock.(ContextAccountLock); ok {
+		return contextLock.ObtainContext(ctx, chainId, address)
+	}
+	lock.Obtain(chainId, address)
+	return nil
+}
+
+// 每个账户一个容量为1的channel，写入即获取锁，读出即释放锁，以便等待时响应ctx
+func (l *accountLock) mutex(chainId, address string) chan struct{} {
+	v, _ := l.locks.LoadOrStore(fmt.Sprintf("%s_%s", chainId, address), make(chan struct{}, 1))
+	return v.(chan struct{})
+}
+
 func (l *accountLock) Obtain(chainId, address string) {
-	v, _ := l.locks.LoadOrStore(fmt.Sprintf("%s_%s", chainId, address), &sync.Mutex{})
-	mutex := v.(*sync.Mutex)
-	mutex.Lock()
+	l.mutex(chainId, address) <- struct{}{}
+}
+
+func (l *accountLock) ObtainContext(ctx context.Context, chainId, address string) error {
+	select {
+	case l.mutex(chainId, address) <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (l *accountLock) Unlock(chainId, address string) {
 	if v, ok := l.locks.Load(fmt.Sprintf("%s_%s", chainId, address)); ok {
-		mutex := v.(*sync.Mutex)
-		mutex.Unlock()
+		select {
+		case <-v.(chan struct{}):
+		default:
+		}
 		// fixme whether to delete the value
 		// _i.locks.Delete(mutexName)
 	}
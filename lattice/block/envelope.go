@@ -0,0 +1,133 @@
+package block
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+)
+
+// EnvelopeVersion 当前交易信封的版本
+const EnvelopeVersion uint = 1
+
+// ErrUnsupportedEnvelopeVersion 不支持的交易信封版本
+var ErrUnsupportedEnvelopeVersion = errors.New("unsupported envelope version")
+
+// Envelope 用于离线签名的交易信封，包含签名所需的全部信息，在线构造交易后导出，
+// 在离线机器上仅依赖 block 和 crypto 包完成签名，再导回在线环境广播
+//   - Version     信封的版本
+//   - ChainId     链ID
+//   - Curve       椭圆曲线
+//   - Transaction 交易，签名前Sign为空
+type Envelope struct {
+	Version     uint         `json:"version"`
+	ChainId     uint64       `json:"chainId"`
+	Curve       types.Curve  `json:"curve"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// NewEnvelope 初始化交易信封
+//
+// Parameters:
+//   - chainId uint64
+//   - curve types.Curve
+//   - transaction *Transaction: 已设置高度、父哈希和守护区块哈希的交易
+//
+// Returns:
+//   - *Envelope
+func NewEnvelope(chainId uint64, curve types.Curve, transaction *Transaction) *Envelope {
+	return &Envelope{
+		Version:     EnvelopeVersion,
+		ChainId:     chainId,
+		Curve:       curve,
+		Transaction: transaction,
+	}
+}
+
+// SigningHash 获取待签名的哈希，可用于在离线机器上核对
+//
+// Returns:
+//   - common.Hash
+//   - error
+func (e *Envelope) SigningHash() (common.Hash, error) {
+	return e.Transaction.rlpEncodeHash(e.ChainId, e.Curve)
+}
+
+// Sign 使用私钥签名信封中的交易
+//
+// Parameters:
+//   - skHex string: 私钥
+//
+// Returns:
+//   - error
+func (e *Envelope) Sign(skHex string) error {
+	return e.Transaction.SignTX(e.ChainId, e.Curve, skHex)
+}
+
+// SignWithSigner 使用签名者签名信封中的交易
+//
+// Parameters:
+//   - signer crypto.Signer: 曲线需要与信封一致
+//
+// Returns:
+//   - error
+func (e *Envelope) SignWithSigner(signer crypto.Signer) error {
+	if signer.Curve() != e.Curve {
+		return fmt.Errorf("signer curve %s does not match the envelope curve %s", signer.Curve(), e.Curve)
+	}
+	return e.Transaction.SignTXWithSigner(e.ChainId, signer)
+}
+
+// IsSigned 交易是否已签名
+func (e *Envelope) IsSigned() bool {
+	return e.Transaction != nil && e.Transaction.Sign != ""
+}
+
+// EncodeJSON 将信封编码为json
+//
+// Returns:
+//   - []byte
+//   - error
+func (e *Envelope) EncodeJSON() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// EncodeRLP 将信封编码为rlp，比json更紧凑，适合二维码等传输方式
+//
+// Returns:
+//   - []byte
+//   - error
+func (e *Envelope) EncodeRLP() ([]byte, error) {
+	return rlp.EncodeToBytes(e)
+}
+
+// DecodeEnvelope 解码json或rlp格式的交易信封
+//
+// Parameters:
+//   - data []byte
+//
+// Returns:
+//   - *Envelope
+//   - error
+func DecodeEnvelope(data []byte) (*Envelope, error) {
+	envelope := new(Envelope)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, envelope); err != nil {
+			return nil, err
+		}
+	} else if err := rlp.DecodeBytes(data, envelope); err != nil {
+		return nil, err
+	}
+
+	if envelope.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedEnvelopeVersion, envelope.Version)
+	}
+	if envelope.Transaction == nil {
+		return nil, errors.New("envelope has no transaction")
+	}
+	return envelope, nil
+}
@@ -0,0 +1,56 @@
+package block
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"testing"
+)
+
+func TestEnvelope_OfflineSign(t *testing.T) {
+	cryptoInstance := crypto.NewCrypto(crypto.Secp256k1)
+	sk, err := cryptoInstance.GenerateKeyPair()
+	assert.NoError(t, err)
+	skHex, err := cryptoInstance.SKToHexString(sk)
+	assert.NoError(t, err)
+	owner, err := cryptoInstance.PKToAddress(&sk.PublicKey)
+	assert.NoError(t, err)
+
+	// online: build the unsigned transaction and export it
+	tx := NewTransactionBuilder(TransactionTypeSend).
+		SetLatestBlock(&types.LatestBlock{Height: 7}).
+		SetOwner(convert.AddressToZltc(owner)).
+		SetLinker(convert.AddressToZltc(owner)).
+		SetPayload(constant.ZeroPayload).
+		SetAmount(10).
+		Build()
+	exported, err := NewEnvelope(1, crypto.Secp256k1, tx).EncodeRLP()
+	assert.NoError(t, err)
+
+	// offline: decode, sign and export again
+	envelope, err := DecodeEnvelope(exported)
+	assert.NoError(t, err)
+	assert.False(t, envelope.IsSigned())
+	signingHash, err := envelope.SigningHash()
+	assert.NoError(t, err)
+	assert.NoError(t, envelope.Sign(skHex))
+	signed, err := envelope.EncodeJSON()
+	assert.NoError(t, err)
+
+	// online: decode the signed envelope and verify before broadcasting
+	envelope, err = DecodeEnvelope(signed)
+	assert.NoError(t, err)
+	assert.True(t, envelope.IsSigned())
+	assert.Equal(t, uint64(8), envelope.Transaction.Height)
+	ok, err := envelope.Transaction.VerifySignature(envelope.ChainId, envelope.Curve)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	resigningHash, err := envelope.SigningHash()
+	assert.NoError(t, err)
+	assert.Equal(t, signingHash, resigningHash)
+
+	_, err = DecodeEnvelope([]byte(`{"version":2,"transaction":{}}`))
+	assert.ErrorIs(t, err, ErrUnsupportedEnvelopeVersion)
+}
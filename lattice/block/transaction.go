@@ -1,6 +1,7 @@
 package block
 
 import (
+	"errors"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -11,6 +12,9 @@ import (
 	"math/big"
 )
 
+// ErrTransactionNotSigned 交易未签名
+var ErrTransactionNotSigned = errors.New("transaction is not signed")
+
 // TransactionType 交易类型别名
 //
 //   - TransactionTypeGenesis			  创世交易
@@ -26,6 +30,9 @@ import (
 //   - TransactionTypeDeployGoContract	  部署Go合约
 //   - TransactionTypeUpgradeGoContract	  升级Go合约
 //   - TransactionTypeCallGoContract	  调用Go合约
+//   - TransactionTypeRevokeContract	  吊销合约
+//   - TransactionTypeFreezeContract	  冻结合约
+//   - TransactionTypeUnfreezeContract	  解冻合约
 type TransactionType string
 
 const (
@@ -42,6 +49,9 @@ const (
 	TransactionTypeDeployGoContract    TransactionType = "createGo"
 	TransactionTypeUpgradeGoContract   TransactionType = "updateGo"
 	TransactionTypeCallGoContract      TransactionType = "executeGo"
+	TransactionTypeRevokeContract      TransactionType = "revoke"
+	TransactionTypeFreezeContract      TransactionType = "freeze"
+	TransactionTypeUnfreezeContract    TransactionType = "unfreeze"
 )
 
 const (
@@ -77,6 +87,9 @@ var TransactionTypeCode = map[TransactionType]uint8{
 	TransactionTypeDeployGoContract:    DeployGoContract,
 	TransactionTypeUpgradeGoContract:   UpgradeGoContract,
 	TransactionTypeCallGoContract:      ExecuteGoContract,
+	TransactionTypeRevokeContract:      RevokeContract,
+	TransactionTypeFreezeContract:      FreezeContract,
+	TransactionTypeUnfreezeContract:    UnfreezeContract,
 }
 
 // Transaction 构造交易的结构体
@@ -147,6 +160,26 @@ func (tx *Transaction) DecodePayload() []byte {
 	return hexutil.MustDecode(tx.Payload)
 }
 
+// 签名哈希和区块哈希共用的RLP字段，签名哈希在其后追加链ID，区块哈希在其后追加签名
+func (tx *Transaction) rlpFields() []interface{} {
+	return []interface{}{
+		tx.Height,
+		tx.GetTypeCode(),
+		tx.ParentHash,
+		tx.Hub,
+		tx.DaemonHash,
+		tx.CodeHash,
+		tx.GetOwnerAddress(),
+		tx.GetLinkerAddress(),
+		tx.Amount,
+		tx.Joule,
+		tx.Difficulty,
+		tx.ProofOfWork,
+		tx.DecodePayload(),
+		tx.Timestamp,
+	}
+}
+
 // RlpEncodeHash 对交易进行rlp编码并计算哈希
 //
 // Parameters:
@@ -156,27 +189,12 @@ func (tx *Transaction) DecodePayload() []byte {
 // Returns:
 //   - common.Hash: 哈希
 func (tx *Transaction) rlpEncodeHash(chainId uint64, curve types.Curve) (common.Hash, error) {
-	var err error
-	hash := crypto.NewCrypto(curve).EncodeHash(func(writer io.Writer) {
-		err = rlp.Encode(writer, []interface{}{
-			tx.Height,
-			tx.GetTypeCode(),
-			tx.ParentHash,
-			tx.Hub,
-			tx.DaemonHash,
-			tx.CodeHash,
-			tx.GetOwnerAddress(),
-			tx.GetLinkerAddress(),
-			tx.Amount,
-			tx.Joule,
-			tx.Difficulty,
-			tx.ProofOfWork,
-			tx.DecodePayload(),
-			tx.Timestamp,
-			chainId,
-			uint(0),
-			uint(0),
-		})
+	api, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := api.EncodeHash(func(writer io.Writer) {
+		err = rlp.Encode(writer, append(tx.rlpFields(), chainId, uint(0), uint(0)))
 	})
 	if err != nil {
 		return common.Hash{}, err
@@ -184,51 +202,137 @@ func (tx *Transaction) rlpEncodeHash(chainId uint64, curve types.Curve) (common.
 	return hash, nil
 }
 
-// SignTx 签名交易
+// SignTX 签名交易
 //
 // Parameters:
-//   - curve types.Curve: 椭圆曲线类型
-//   - hash []byte: 哈希
-//   - skHex string: 私钥
+//   - chainId *big.Int
+//   - curve types.Curve
+//   - skHex string
 //
 // Returns:
-//   - []byte: 签名
 //   - error
-func (tx *Transaction) sign(curve types.Curve, hash []byte, skHex string) ([]byte, error) {
-	cryptoInstance := crypto.NewCrypto(curve)
+func (tx *Transaction) SignTX(chainId uint64, curve types.Curve, skHex string) error {
+	signer, err := crypto.NewMemorySignerFromHex(curve, skHex)
+	if err != nil {
+		return err
+	}
+	return tx.SignTXWithSigner(chainId, signer)
+}
 
-	sk, err := cryptoInstance.HexToSK(skHex)
+// SignTXWithSigner 使用签名者签名交易，私钥不需要出现在当前进程中
+//
+// Parameters:
+//   - chainId uint64
+//   - signer crypto.Signer
+//
+// Returns:
+//   - error
+func (tx *Transaction) SignTXWithSigner(chainId uint64, signer crypto.Signer) error {
+	curve := signer.Curve()
+	hash, err := tx.rlpEncodeHash(chainId, curve)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	signature, err := signer.Sign(hash[:])
+	if err != nil {
+		return err
+	}
+	tx.Sign = hexutil.Encode(signature)
 
-	sign, err := cryptoInstance.Sign(hash, sk)
+	blockHash, err := tx.BlockHash(curve)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	tx.Hash = blockHash.Hex()
 
-	return sign, nil
+	return nil
 }
 
-// SignTX 签名交易
+// RecoverOwner 从签名中恢复签名者的地址
 //
 // Parameters:
-//   - chainId *big.Int
+//   - chainId uint64
 //   - curve types.Curve
-//   - skHex string
 //
 // Returns:
+//   - common.Address: 签名者的地址
 //   - error
-func (tx *Transaction) SignTX(chainId uint64, curve types.Curve, skHex string) error {
+func (tx *Transaction) RecoverOwner(chainId uint64, curve types.Curve) (common.Address, error) {
+	if tx.Sign == "" {
+		return common.Address{}, ErrTransactionNotSigned
+	}
+	signature, err := hexutil.Decode(tx.Sign)
+	if err != nil {
+		return common.Address{}, err
+	}
 	hash, err := tx.rlpEncodeHash(chainId, curve)
 	if err != nil {
-		return err
+		return common.Address{}, err
 	}
-	signature, err := tx.sign(curve, hash[:], skHex)
+	cryptoInstance, err := crypto.GetCrypto(curve)
 	if err != nil {
-		return err
+		return common.Address{}, err
 	}
-	tx.Sign = hexutil.Encode(signature)
+	return cryptoInstance.SignatureToAddress(hash.Bytes(), signature)
+}
 
-	return nil
+// VerifySignature 验证交易是否由Owner签名，且已填充的Hash与本地计算的哈希一致
+//
+// Parameters:
+//   - chainId uint64
+//   - curve types.Curve
+//
+// Returns:
+//   - bool: 签名者为Owner且哈希一致时为true
+//   - error: 签名无法解析或恢复时返回
+func (tx *Transaction) VerifySignature(chainId uint64, curve types.Curve) (bool, error) {
+	signer, err := tx.RecoverOwner(chainId, curve)
+	if errors.Is(err, crypto.ErrSignatureMismatch) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if signer != tx.GetOwnerAddress() {
+		return false, nil
+	}
+	if tx.Hash == "" {
+		return true, nil
+	}
+	blockHash, err := tx.BlockHash(curve)
+	if err != nil {
+		return false, err
+	}
+	return blockHash == common.HexToHash(tx.Hash), nil
+}
+
+// BlockHash 在本地计算已签名交易的区块哈希，SignTX 后会填充到 Hash 字段，
+// 编码方式与签名哈希一致，只是将末尾的 chainId, 0, 0 替换为签名。
+// 该算法还未与节点 wallet_sendRawTBlock、latc_getTBlockByHash 返回的哈希核对，不能当作节点返回的交易哈希使用
+//
+// Parameters:
+//   - curve types.Curve: 椭圆曲线
+//
+// Returns:
+//   - common.Hash: 哈希
+//   - error
+func (tx *Transaction) BlockHash(curve types.Curve) (common.Hash, error) {
+	if tx.Sign == "" {
+		return common.Hash{}, ErrTransactionNotSigned
+	}
+	signature, err := hexutil.Decode(tx.Sign)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	api, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := api.EncodeHash(func(writer io.Writer) {
+		err = rlp.Encode(writer, append(tx.rlpFields(), signature))
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
 }
@@ -0,0 +1,119 @@
+package block
+
+import (
+	"crypto/sha256"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func newSignedTestTransaction(t *testing.T, curve types.Curve) *Transaction {
+	cryptoInstance := crypto.NewCrypto(curve)
+	sk, err := cryptoInstance.GenerateKeyPair()
+	assert.NoError(t, err)
+	skHex, err := cryptoInstance.SKToHexString(sk)
+	assert.NoError(t, err)
+	owner, err := cryptoInstance.PKToAddress(&sk.PublicKey)
+	assert.NoError(t, err)
+
+	tx := NewTransactionBuilder(TransactionTypeSend).
+		SetLatestBlock(&types.LatestBlock{Height: 1}).
+		SetOwner(convert.AddressToZltc(owner)).
+		SetLinker(convert.AddressToZltc(owner)).
+		SetPayload(constant.ZeroPayload).
+		SetAmount(10).
+		Build()
+	assert.NoError(t, tx.SignTX(1, curve, skHex))
+	return tx
+}
+
+func TestTransaction_VerifySignature(t *testing.T) {
+	for _, curve := range []types.Curve{crypto.Secp256k1, crypto.Sm2p256v1} {
+		tx := newSignedTestTransaction(t, curve)
+		blockHash, err := tx.BlockHash(curve)
+		assert.NoError(t, err)
+		assert.Equal(t, blockHash.Hex(), tx.Hash)
+
+		owner, err := tx.RecoverOwner(1, curve)
+		assert.NoError(t, err)
+		assert.Equal(t, tx.GetOwnerAddress(), owner)
+		ok, err := tx.VerifySignature(1, curve)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// signed for another chain
+		ok, err = tx.VerifySignature(2, curve)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		// tampered after signing
+		tx.Amount = big.NewInt(1000)
+		ok, err = tx.VerifySignature(1, curve)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+
+	_, err := (&Transaction{}).BlockHash(crypto.Secp256k1)
+	assert.ErrorIs(t, err, ErrTransactionNotSigned)
+}
+
+// 固定字段和签名的交易，签名哈希与区块哈希仅末尾字段不同
+func newKnownAnswerTransaction() *Transaction {
+	return &Transaction{
+		Height:     2,
+		Type:       TransactionTypeSend,
+		ParentHash: common.HexToHash("0x1d3f5a0c6b2e47f18a9c0d7e6b5a4f3e2d1c0b9a8f7e6d5c4b3a291807f6e5d4"),
+		DaemonHash: common.HexToHash("0x9b2c7d4e1f0a3b6c5d8e7f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c"),
+		Owner:      "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi",
+		Linker:     "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi",
+		Amount:     big.NewInt(10),
+		Payload:    constant.ZeroPayload,
+		Timestamp:  1700000000,
+		Sign:       "0x" + strings.Repeat("ab", 65),
+	}
+}
+
+// SDK 自身的回归向量：按 rlpFields 的字段顺序独立编码交易并固定计算结果，只能发现本地编码的变化，
+// 不能证明与节点的哈希一致，取得节点 wallet_sendRawTBlock、latc_getTBlockByHash 的真实响应后应替换为节点返回的哈希
+func TestTransaction_BlockHash_KnownAnswer(t *testing.T) {
+	tx := newKnownAnswerTransaction()
+	owner, err := convert.ZltcToAddress(tx.Owner)
+	assert.NoError(t, err)
+	fields := []interface{}{
+		uint64(2), uint8(Send), tx.ParentHash, []common.Hash{}, tx.DaemonHash, common.Hash{},
+		owner, owner, big.NewInt(10), new(big.Int), uint64(0), new(big.Int), []byte{}, uint64(1700000000),
+	}
+	blockEncoded, err := rlp.EncodeToBytes(append(fields, hexutil.MustDecode(tx.Sign)))
+	assert.NoError(t, err)
+	signEncoded, err := rlp.EncodeToBytes(append(fields, uint64(1), uint(0), uint(0)))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		curve               types.Curve
+		hash                func([]byte) common.Hash
+		blockHash, signHash string
+	}{
+		{crypto.Secp256k1, func(b []byte) common.Hash { return sha256.Sum256(b) }, "0xd7bd5d04d30bec6a413a00112f6b33846c41c7893f835e2950caa903e52499fe", "0x1bbcf051b21691f501ba1df6f55af1dfa2053744cceeb61c331aa4703f1b3f68"},
+		{crypto.Sm2p256v1, func(b []byte) common.Hash { return common.BytesToHash(sm3.Sm3Sum(b)) }, "0x39cd14905094f707d2d2ef7b9a8a0e387adb34d73618b4e95e864cf63626bd40", "0xdd1fad2192edbd2b84f2259d77d7afc5567a0f43c9ac7f09f48354a73ac0730e"},
+	}
+	for _, c := range cases {
+		blockHash, err := tx.BlockHash(c.curve)
+		assert.NoError(t, err)
+		assert.Equal(t, c.hash(blockEncoded), blockHash)
+		assert.Equal(t, c.blockHash, blockHash.Hex())
+
+		signHash, err := tx.rlpEncodeHash(1, c.curve)
+		assert.NoError(t, err)
+		assert.Equal(t, c.hash(signEncoded), signHash)
+		assert.Equal(t, c.signHash, signHash.Hex())
+	}
+}
@@ -0,0 +1,194 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrOwnerMismatch 签名者与交易区块的Owner不一致
+	ErrOwnerMismatch = errors.New("the signer does not match the owner of the transaction block")
+	// ErrBlockHashMismatch 本地计算的区块哈希与交易区块的哈希不一致
+	ErrBlockHashMismatch = errors.New("the computed hash does not match the hash of the transaction block")
+)
+
+// FromTransactionBlock 将节点返回的交易区块还原为交易，用于在本地重新计算签名哈希
+//
+// Parameters:
+//   - tBlock *types.TransactionBlock
+//
+// Returns:
+//   - *Transaction
+//   - error: 交易类型未知或字段无法解析时返回
+func FromTransactionBlock(tBlock *types.TransactionBlock) (*Transaction, error) {
+	if tBlock == nil {
+		return nil, errors.New("transaction block is nil")
+	}
+	transactionType := TransactionType(tBlock.Type)
+	if _, ok := TransactionTypeCode[transactionType]; !ok {
+		return nil, fmt.Errorf("unknown transaction type %q", tBlock.Type)
+	}
+	if _, err := convert.ZltcToAddress(tBlock.Owner); err != nil {
+		return nil, fmt.Errorf("invalid owner %q: %w", tBlock.Owner, err)
+	}
+	// 节点对没有Linker的交易(如部署合约)返回空字符串，签名时使用的是零地址
+	linker := tBlock.Linker
+	if linker == "" {
+		linker = constant.ZeroAddress
+	}
+	if _, err := convert.ZltcToAddress(linker); err != nil {
+		return nil, fmt.Errorf("invalid linker %q: %w", tBlock.Linker, err)
+	}
+	payload := tBlock.Payload
+	if payload == "" {
+		payload = "0x"
+	}
+	if _, err := hexutil.Decode(payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	amount, err := parseBigInt(tBlock.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", tBlock.Amount, err)
+	}
+	pow, err := parseBigInt(tBlock.Pow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof of work %q: %w", tBlock.Pow, err)
+	}
+
+	var height uint64
+	if tBlock.Height != nil {
+		height = tBlock.Height.Uint64()
+	}
+	hub := make([]common.Hash, len(tBlock.Hub))
+	for i, hash := range tBlock.Hub {
+		hub[i] = common.HexToHash(hash)
+	}
+	var hash string
+	if tBlock.Hash != (common.Hash{}) {
+		hash = tBlock.Hash.Hex()
+	}
+
+	return &Transaction{
+		Height:      height,
+		Type:        transactionType,
+		ParentHash:  tBlock.ParentHash,
+		Hub:         hub,
+		DaemonHash:  tBlock.DaemonHash,
+		CodeHash:    common.HexToHash(tBlock.CodeHash),
+		Owner:       tBlock.Owner,
+		Linker:      linker,
+		Amount:      amount,
+		Joule:       new(big.Int).SetUint64(tBlock.Joule),
+		Difficulty:  tBlock.Difficulty,
+		ProofOfWork: pow,
+		Payload:     payload,
+		Timestamp:   tBlock.Timestamp,
+		Code:        tBlock.Code,
+		Sign:        tBlock.Sign,
+		Hash:        hash,
+	}, nil
+}
+
+// 解析10进制或0x开头的16进制整数，空字符串为0
+func parseBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return hexutil.DecodeBig(s)
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, errors.New("not an integer")
+	}
+	return n, nil
+}
+
+// Verifier 独立验证节点返回的交易区块是否由其Owner签名，不依赖节点的验证结果
+type Verifier interface {
+	// VerifyTransactionBlock 验证交易区块的签名
+	//
+	// Parameters:
+	//   - tBlock *types.TransactionBlock
+	//
+	// Returns:
+	//   - error: 验证通过时为nil，签名者不是Owner时为 ErrOwnerMismatch，区块哈希不一致时为 ErrBlockHashMismatch
+	VerifyTransactionBlock(tBlock *types.TransactionBlock) error
+
+	// VerifyTransactionBlocks 批量验证交易区块的签名，如 types.TransactionsPagination 中的交易
+	//
+	// Parameters:
+	//   - tBlocks []*types.TransactionBlock
+	//
+	// Returns:
+	//   - error: 全部验证通过时为nil，否则合并所有失败的区块的错误
+	VerifyTransactionBlocks(tBlocks []*types.TransactionBlock) error
+}
+
+// NewVerifier 初始化交易区块的验证器
+//
+// Parameters:
+//   - chainId uint64: 链ID
+//   - curve types.Curve: 链使用的椭圆曲线
+//
+// Returns:
+//   - Verifier
+func NewVerifier(chainId uint64, curve types.Curve) Verifier {
+	return &verifier{chainId: chainId, curve: curve}
+}
+
+type verifier struct {
+	chainId uint64
+	curve   types.Curve
+}
+
+func (v *verifier) VerifyTransactionBlock(tBlock *types.TransactionBlock) error {
+	tx, err := FromTransactionBlock(tBlock)
+	if err != nil {
+		return err
+	}
+	signer, err := tx.RecoverOwner(v.chainId, v.curve)
+	if errors.Is(err, crypto.ErrSignatureMismatch) {
+		return fmt.Errorf("%w: %v", ErrOwnerMismatch, err)
+	}
+	if err != nil {
+		return err
+	}
+	if owner := tx.GetOwnerAddress(); signer != owner {
+		return fmt.Errorf("%w: expected %s, got %s", ErrOwnerMismatch, convert.AddressToZltc(owner), convert.AddressToZltc(signer))
+	}
+	if tx.Hash == "" {
+		return nil
+	}
+	blockHash, err := tx.BlockHash(v.curve)
+	if err != nil {
+		return err
+	}
+	if blockHash != tBlock.Hash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBlockHashMismatch, tBlock.Hash.Hex(), blockHash.Hex())
+	}
+	return nil
+}
+
+func (v *verifier) VerifyTransactionBlocks(tBlocks []*types.TransactionBlock) error {
+	var errList []error
+	for i, tBlock := range tBlocks {
+		if err := v.VerifyTransactionBlock(tBlock); err != nil {
+			if tBlock != nil {
+				err = fmt.Errorf("transaction block %s: %w", tBlock.Hash.Hex(), err)
+			} else {
+				err = fmt.Errorf("transaction block at index %d: %w", i, err)
+			}
+			errList = append(errList, err)
+		}
+	}
+	return errors.Join(errList...)
+}
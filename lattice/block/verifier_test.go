@@ -0,0 +1,113 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 模拟节点返回的交易区块
+func toTransactionBlock(tx *Transaction) *types.TransactionBlock {
+	hub := make([]string, len(tx.Hub))
+	for i, hash := range tx.Hub {
+		hub[i] = hash.Hex()
+	}
+	var joule uint64
+	if tx.Joule != nil {
+		joule = tx.Joule.Uint64()
+	}
+	pow := "0x0"
+	if tx.ProofOfWork != nil {
+		pow = hexutil.EncodeBig(tx.ProofOfWork)
+	}
+	return &types.TransactionBlock{
+		Height:     new(big.Int).SetUint64(tx.Height),
+		Hash:       common.HexToHash(tx.Hash),
+		ParentHash: tx.ParentHash,
+		DaemonHash: tx.DaemonHash,
+		Payload:    tx.Payload,
+		Hub:        hub,
+		Timestamp:  tx.Timestamp,
+		Type:       string(tx.Type),
+		Owner:      tx.Owner,
+		Linker:     tx.Linker,
+		CodeHash:   tx.CodeHash.Hex(),
+		Amount:     tx.Amount.String(),
+		Joule:      joule,
+		Difficulty: tx.Difficulty,
+		Sign:       tx.Sign,
+		Pow:        pow,
+	}
+}
+
+func TestVerifier_VerifyTransactionBlock(t *testing.T) {
+	for _, curve := range []types.Curve{crypto.Secp256k1, crypto.Sm2p256v1} {
+		tx := newSignedTestTransaction(t, curve)
+		verifier := NewVerifier(1, curve)
+
+		tBlock := toTransactionBlock(tx)
+		assert.NoError(t, verifier.VerifyTransactionBlock(tBlock))
+
+		// signed for another chain
+		assert.ErrorIs(t, NewVerifier(2, curve).VerifyTransactionBlock(tBlock), ErrOwnerMismatch)
+
+		// owner replaced by the node
+		other := crypto.NewCrypto(curve)
+		sk, err := other.GenerateKeyPair()
+		assert.NoError(t, err)
+		address, err := other.PKToAddress(&sk.PublicKey)
+		assert.NoError(t, err)
+		forged := toTransactionBlock(tx)
+		forged.Owner = convert.AddressToZltc(address)
+		assert.ErrorIs(t, verifier.VerifyTransactionBlock(forged), ErrOwnerMismatch)
+
+		// hash not matching the signed content
+		forged = toTransactionBlock(tx)
+		forged.Hash = common.HexToHash("0x01")
+		assert.ErrorIs(t, verifier.VerifyTransactionBlock(forged), ErrBlockHashMismatch)
+
+		err = verifier.VerifyTransactionBlocks([]*types.TransactionBlock{tBlock, forged, nil})
+		assert.ErrorIs(t, err, ErrBlockHashMismatch)
+		assert.ErrorContains(t, err, "index 2")
+		assert.NoError(t, verifier.VerifyTransactionBlocks([]*types.TransactionBlock{tBlock}))
+	}
+}
+
+// 读取按 latc_getTBlockByHash 响应格式构造的合成数据，与客户端一样直接解析为 types.TransactionBlock，
+// 数据的哈希由 SDK 自身计算，不是节点的真实响应，见 testdata/README.md
+func loadSyntheticTransactionBlock(t *testing.T, curve types.Curve) *types.TransactionBlock {
+	data, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("synthetic_tblock_%s.json", curve)))
+	assert.NoError(t, err)
+	var response struct {
+		Result *types.TransactionBlock `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &response))
+	return response.Result
+}
+
+func TestVerifier_VerifyTransactionBlock_Synthetic(t *testing.T) {
+	for _, curve := range []types.Curve{crypto.Secp256k1, crypto.Sm2p256v1} {
+		tBlock := loadSyntheticTransactionBlock(t, curve)
+		verifier := NewVerifier(1, curve)
+		assert.NoError(t, verifier.VerifyTransactionBlock(tBlock), curve)
+
+		// 部署合约的Linker为空，签名时使用零地址
+		assert.Empty(t, tBlock.Linker)
+		tx, err := FromTransactionBlock(tBlock)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.ZeroAddress, tx.Linker)
+
+		tBlock.Amount = "1"
+		assert.ErrorIs(t, verifier.VerifyTransactionBlock(tBlock), ErrOwnerMismatch, curve)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/allegro/bigcache/v3"
-	"github.com/rs/zerolog/log"
+	"github.com/wylu1037/lattice-go/common/logger"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/lattice/client"
 	"sync"
@@ -116,7 +116,22 @@ type BlockCache interface {
 	GetBlock(chainId, address string) (*types.LatestBlock, error)
 }
 
-// type redisBlockCache struct{}
+// HitReportingBlockCache 能够报告查询是否命中的区块缓存，NewInstrumentedBlockCache 据此记录命中率
+type HitReportingBlockCache interface {
+	BlockCache
+
+	// GetBlockWithHit 获取区块缓存，并返回是否命中
+	//
+	// Parameters:
+	//   - chainId string
+	//   - address string
+	//
+	// Returns:
+	//   - *types.LatestBlock: 缓存的区块信息
+	//   - bool: 需要请求节点获取最新区块时为false
+	//   - error
+	GetBlockWithHit(chainId, address string) (*types.LatestBlock, bool, error)
+}
 
 type memoryBlockCache struct {
 	enable                       bool               // 是否启用缓存
@@ -136,11 +151,11 @@ func (c *memoryBlockCache) SetBlock(chainId, address string, block *types.Latest
 	}
 	bytes, err := json.Marshal(block)
 	if err != nil {
-		log.Error().Err(err).Msgf("json序列化block失败，chainId: %s, accountAddress: %s", chainId, address)
+		logger.Error("Failed to serialize the block", "json序列化block失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
 		return err
 	}
 	if err := c.memoryCacheApi.Set(fmt.Sprintf("%s_%s", chainId, address), bytes); err != nil {
-		log.Error().Err(err).Msgf("设置区块缓存信息失败，chainId: %s, accountAddress: %s", chainId, address)
+		logger.Error("Failed to set the block cache", "设置区块缓存信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
 		return err
 	}
 
@@ -153,22 +168,29 @@ func (c *memoryBlockCache) SetBlock(chainId, address string, block *types.Latest
 }
 
 func (c *memoryBlockCache) GetBlock(chainId, address string) (*types.LatestBlock, error) {
+	block, _, err := c.GetBlockWithHit(chainId, address)
+	return block, err
+}
+
+func (c *memoryBlockCache) GetBlockWithHit(chainId, address string) (*types.LatestBlock, bool, error) {
 	if !c.enable {
-		return c.httpApi.GetLatestBlock(context.Background(), chainId, address)
+		block, err := c.httpApi.GetLatestBlock(client.WithAccountRouting(context.Background(), chainId, address), chainId, address)
+		return block, false, err
 	}
 	// load cached block from memory
 	cacheBlockBytes, err := c.memoryCacheApi.Get(fmt.Sprintf("%s_%s", chainId, address))
 	if err != nil {
 		if errors.Is(err, bigcache.ErrEntryNotFound) {
-			return c.httpApi.GetLatestBlock(context.Background(), chainId, address)
+			block, err := c.httpApi.GetLatestBlock(client.WithAccountRouting(context.Background(), chainId, address), chainId, address)
+			return block, false, err
 		}
-		log.Error().Err(err).Msgf("获取区块缓存信息失败，chainId: %s, accountAddress: %s", chainId, address)
-		return nil, err
+		logger.Error("Failed to get the block cache", "获取区块缓存信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return nil, false, err
 	}
 	cacheBlock := new(types.LatestBlock)
 	if err := json.Unmarshal(cacheBlockBytes, cacheBlock); err != nil {
-		log.Error().Err(err).Msgf("json序列化block失败，chainId: %s, accountAddress: %s", chainId, address)
-		return nil, err
+		logger.Error("Failed to serialize the block", "json序列化block失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return nil, false, err
 	}
 	// judge daemon hash expiration time
 	daemonHashExpireAt, ok := c.daemonHashExpireAtMap.Load(chainId)
@@ -177,15 +199,16 @@ func (c *memoryBlockCache) GetBlock(chainId, address string) (*types.LatestBlock
 		c.daemonHashExpireAtMap.LoadOrStore(chainId, daemonHashExpireAt)
 	}
 	if time.Now().After(daemonHashExpireAt.(time.Time)) {
-		log.Debug().Msgf("守护区块哈希已过期，开始更新守护区块哈希，chainId: %s, accountAddress: %s", chainId, address)
-		block, err := c.httpApi.GetLatestBlock(context.Background(), chainId, address)
+		logger.Debug("The daemon block hash has expired, updating it", "守护区块哈希已过期，开始更新守护区块哈希", logger.String("chainId", chainId), logger.String("accountAddress", address))
+		block, err := c.httpApi.GetLatestBlock(client.WithAccountRouting(context.Background(), chainId, address), chainId, address)
 		if err != nil {
-			log.Error().Err(err).Msgf("请求节点获取最新区块信息失败，chainId: %s, accountAddress: %s", chainId, address)
-			return nil, err
+			logger.Error("Failed to get the latest block from the node", "请求节点获取最新区块信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+			return nil, false, err
 		}
 		c.daemonHashExpireAtMap.Store(chainId, time.Now().Add(c.daemonHashExpirationDuration))
 		cacheBlock.DaemonBlockHash = block.DaemonBlockHash
+		return cacheBlock, false, nil
 	}
 
-	return cacheBlock, nil
+	return cacheBlock, true, nil
 }
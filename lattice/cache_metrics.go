@@ -0,0 +1,45 @@
+package lattice
+
+import (
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+)
+
+// NewInstrumentedBlockCache 记录区块缓存的命中率，查询时需要请求节点获取最新区块的记为未命中，
+// 区块缓存需要实现 HitReportingBlockCache，否则不记录命中率
+//
+// Parameters:
+//   - blockCache BlockCache
+//   - recorder client.MetricsRecorder
+//
+// Returns:
+//   - BlockCache
+func NewInstrumentedBlockCache(blockCache BlockCache, recorder client.MetricsRecorder) BlockCache {
+	return &instrumentedBlockCache{
+		BlockCache: blockCache,
+		recorder:   recorder,
+	}
+}
+
+type instrumentedBlockCache struct {
+	BlockCache
+	recorder client.MetricsRecorder
+}
+
+func (c *instrumentedBlockCache) GetBlock(chainId, address string) (*types.LatestBlock, error) {
+	block, _, err := c.GetBlockWithHit(chainId, address)
+	return block, err
+}
+
+func (c *instrumentedBlockCache) GetBlockWithHit(chainId, address string) (*types.LatestBlock, bool, error) {
+	blockCache, ok := c.BlockCache.(HitReportingBlockCache)
+	if !ok {
+		block, err := c.BlockCache.GetBlock(chainId, address)
+		return block, false, err
+	}
+	block, hit, err := blockCache.GetBlockWithHit(chainId, address)
+	if err == nil {
+		c.recorder.ObserveBlockCache(hit)
+	}
+	return block, hit, err
+}
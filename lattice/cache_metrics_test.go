@@ -0,0 +1,50 @@
+package lattice
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstrumentedBlockCache(t *testing.T) {
+	registry := client.NewMetricsRegistry()
+	blockCache := NewInstrumentedBlockCache(NewMemoryBlockCache(time.Minute, time.Minute, time.Minute), registry)
+	blockCache.SetHttpApi(new(latestBlockHttpApi))
+
+	latestBlock, err := blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.NoError(t, blockCache.SetBlock(chainId, credentials.AccountAddress, latestBlock))
+	_, err = blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	_, err = blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2.0/3.0, registry.BlockCacheHitRate(), 1e-9)
+}
+
+func TestInstrumentedBlockCache_Redis(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	registry := client.NewMetricsRegistry()
+	blockCache := NewInstrumentedBlockCache(NewRedisBlockCache(rdb, nil, nil), registry)
+	blockCache.SetHttpApi(new(latestBlockHttpApi))
+
+	// concurrent lookups of different accounts do not affect each other
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := blockCache.GetBlock(chainId, credentials.AccountAddress)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, registry.BlockCacheHitRate())
+
+	assert.NoError(t, blockCache.SetBlock(chainId, credentials.AccountAddress, &types.LatestBlock{Height: 1}))
+	_, err := blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0/5.0, registry.BlockCacheHitRate(), 1e-9)
+}
@@ -0,0 +1,118 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/crypto"
+	"strconv"
+)
+
+// ErrCurveMismatch 配置的椭圆曲线与节点的不一致，使用错误的曲线签名的交易会被节点拒绝
+var ErrCurveMismatch = errors.New("the configured curve does not match the node")
+
+func (svc *lattice) RegisterChain(chainId string, chainConfig *ChainConfig) error {
+	if chainConfig == nil {
+		return errors.New("ChainConfig不能为nil")
+	}
+	if _, err := strconv.ParseUint(chainId, 10, 64); err != nil {
+		return fmt.Errorf("链ID【%s】不合法：%w", chainId, err)
+	}
+	if err := chainConfig.validate(svc.options.DiscoverChainConfig); err != nil {
+		return err
+	}
+
+	svc.chainConfigsMu.Lock()
+	defer svc.chainConfigsMu.Unlock()
+	if svc.chains == nil {
+		svc.chains = make(map[string]*ChainConfig)
+	}
+	svc.chains[chainId] = chainConfig
+	// 重新注册时丢弃已生效的配置，下次使用时重新生成
+	delete(svc.chainConfigs, chainId)
+	return nil
+}
+
+// GetChainConfig 获取链配置，优先使用 RegisterChain 注册的配置，未注册时使用默认的链配置，
+// 开启自动发现时，每个链ID首次使用时从节点查询曲线等配置，结果会缓存
+func (svc *lattice) GetChainConfig(ctx context.Context, chainId string) (*ChainConfig, error) {
+	svc.chainConfigsMu.RLock()
+	chainConfig, ok := svc.chainConfigs[chainId]
+	base, registered := svc.chains[chainId]
+	svc.chainConfigsMu.RUnlock()
+	if ok {
+		return chainConfig, nil
+	}
+
+	id, err := strconv.ParseUint(chainId, 10, 64)
+	if err != nil {
+		svc.logger.Error("Invalid chain id", "链ID不合法", logger.String("chainId", chainId), logger.Err(err))
+		return nil, err
+	}
+	if !registered {
+		base = svc.chainConfig
+	}
+	chainConfig = base.clone()
+	chainConfig.id = id
+	if svc.options.DiscoverChainConfig {
+		if err := svc.discoverChainConfig(ctx, chainId, chainConfig); err != nil {
+			return nil, err
+		}
+	}
+	if err := chainConfig.validate(false); err != nil {
+		return nil, err
+	}
+
+	svc.chainConfigsMu.Lock()
+	defer svc.chainConfigsMu.Unlock()
+	// 并发首次使用时以先写入的为准
+	if cached, ok := svc.chainConfigs[chainId]; ok {
+		return cached, nil
+	}
+	if svc.chainConfigs == nil {
+		svc.chainConfigs = make(map[string]*ChainConfig)
+	}
+	svc.chainConfigs[chainId] = chainConfig
+	return chainConfig, nil
+}
+
+// 从节点查询链的配置并填充到chainConfig，chainConfig配置了曲线时校验与节点是否一致
+func (svc *lattice) discoverChainConfig(ctx context.Context, chainId string, chainConfig *ChainConfig) error {
+	cancelCtx, cancelFunc := context.WithTimeout(ctx, svc.options.httpRequestTimeout())
+	defer cancelFunc()
+
+	discovered := new(ChainConfig)
+	if configuration, err := svc.httpApi.GetNodeConfirmedConfiguration(cancelCtx, chainId); err == nil {
+		discovered.Curve = lo.Ternary(configuration.IsGM, crypto.Sm2p256v1, crypto.Secp256k1)
+		discovered.TokenLess = configuration.Tokenless
+		discovered.Consensus = configuration.Consensus
+		discovered.Period = uint(configuration.Period)
+		discovered.Epoch = uint(configuration.Epoch)
+	} else {
+		svc.logger.Debug("Failed to get the confirmed configuration of the node, falling back to the chain info", "获取节点确认的配置信息失败，改为查询链信息", logger.String("chainId", chainId), logger.Err(err))
+		info, err := svc.httpApi.GetLatcInfo(cancelCtx, chainId)
+		if err != nil {
+			svc.logger.Error("Failed to discover the chain configuration", "自动发现链配置失败", logger.String("chainId", chainId), logger.Err(err))
+			return err
+		}
+		discovered.Curve = lo.Ternary(info.IsGM, crypto.Sm2p256v1, crypto.Secp256k1)
+		discovered.TokenLess = info.Tokenless
+		discovered.Consensus = info.Consensus
+		discovered.Period = info.Period
+		discovered.Epoch = info.Epoch
+	}
+
+	if chainConfig.Curve != "" && chainConfig.Curve != discovered.Curve {
+		svc.logger.Error("The configured curve differs from the curve of the node", "配置的椭圆曲线与节点的不一致", logger.String("chainId", chainId), logger.Any("configured", chainConfig.Curve), logger.Any("discovered", discovered.Curve))
+		return fmt.Errorf("%w: configured %s, chain %s uses %s", ErrCurveMismatch, chainConfig.Curve, chainId, discovered.Curve)
+	}
+	chainConfig.Curve = discovered.Curve
+	chainConfig.TokenLess = discovered.TokenLess
+	chainConfig.Consensus = discovered.Consensus
+	chainConfig.Period = discovered.Period
+	chainConfig.Epoch = discovered.Epoch
+	svc.logger.Debug("Discovered the chain configuration", "自动发现链配置", logger.String("chainId", chainId), logger.Any("curve", chainConfig.Curve), logger.Bool("tokenless", chainConfig.TokenLess), logger.String("consensus", chainConfig.Consensus))
+	return nil
+}
@@ -0,0 +1,79 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"testing"
+)
+
+type discoveryHttpApi struct {
+	client.HttpApi
+	queries int
+}
+
+func (api *discoveryHttpApi) GetNodeConfirmedConfiguration(_ context.Context, chainId string) (*types.NodeConfirmedConfiguration, error) {
+	api.queries++
+	if chainId != "1" {
+		return nil, errors.New("unknown chain")
+	}
+	return &types.NodeConfirmedConfiguration{IsGM: true, Tokenless: true, Consensus: "POA", Period: 3}, nil
+}
+
+func (api *discoveryHttpApi) GetLatcInfo(context.Context, string) (*types.NodeProtocolConfig, error) {
+	return &types.NodeProtocolConfig{IsGM: false, Consensus: "PBFT"}, nil
+}
+
+func TestLattice_GetChainConfig(t *testing.T) {
+	httpApi := new(discoveryHttpApi)
+	svc := &lattice{httpApi: httpApi, chainConfig: &ChainConfig{}, options: &Options{DiscoverChainConfig: true}}
+
+	chainConfig, err := svc.GetChainConfig(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Sm2p256v1, chainConfig.Curve)
+	assert.True(t, chainConfig.TokenLess)
+	assert.Equal(t, uint(3), chainConfig.Period)
+	_, err = svc.GetChainConfig(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpApi.queries)
+
+	// falls back to latc info
+	chainConfig, err = svc.GetChainConfig(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Secp256k1, chainConfig.Curve)
+	assert.Equal(t, "PBFT", chainConfig.Consensus)
+
+	svc = &lattice{httpApi: new(discoveryHttpApi), chainConfig: &ChainConfig{Curve: crypto.Secp256k1}, options: &Options{DiscoverChainConfig: true}}
+	_, err = svc.GetChainConfig(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrCurveMismatch)
+}
+
+func TestLattice_RegisterChain(t *testing.T) {
+	svc := &lattice{chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1}, options: &Options{}}
+	assert.NoError(t, svc.RegisterChain("3", &ChainConfig{Curve: crypto.Secp256k1, DefaultJoule: 100}))
+	assert.Error(t, svc.RegisterChain("sub", &ChainConfig{Curve: crypto.Secp256k1}))
+	assert.Error(t, svc.RegisterChain("4", &ChainConfig{}))
+
+	mainChain, err := svc.GetChainConfig(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Sm2p256v1, mainChain.Curve)
+	assert.Equal(t, uint64(1), mainChain.id)
+
+	subChain, err := svc.GetChainConfig(context.Background(), "3")
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Secp256k1, subChain.Curve)
+	assert.Equal(t, uint64(100), subChain.DefaultJoule)
+	assert.Equal(t, uint64(3), subChain.id)
+
+	// re-registering replaces the effective config
+	assert.NoError(t, svc.RegisterChain("3", &ChainConfig{Curve: crypto.Secp256k1, DefaultJoule: 200}))
+	subChain, err = svc.GetChainConfig(context.Background(), "3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), subChain.DefaultJoule)
+
+	_, err = svc.GetChainConfig(context.Background(), "main")
+	assert.Error(t, err)
+}
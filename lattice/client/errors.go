@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wylu1037/lattice-go/common/codes"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"net"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC 2.0 规范定义的错误码
+const (
+	rpcParseErrorCode     int16 = -32700
+	rpcInvalidRequestCode int16 = -32600
+	rpcMethodNotFoundCode int16 = -32601
+	rpcInvalidParamsCode  int16 = -32602
+)
+
+// RpcError 节点返回的JSON-RPC错误，保留了节点的错误码和错误信息，
+// 能够识别的错误可以通过 errors.Is 匹配 errs 包中的错误，例如 errors.Is(err, errs.ErrMethodNotFound)，
+// 节点特有的错误需要先通过 RegisterRpcErrorCode 或 RegisterRpcErrorMessage 注册
+type RpcError struct {
+	Code    int16  // 节点的错误码
+	Message string // 节点的错误信息
+	kind    error  // 识别出的错误类型，无法识别时为nil
+}
+
+func (e *RpcError) Error() string {
+	return fmt.Sprintf("%d:%s", e.Code, e.Message)
+}
+
+func (e *RpcError) Unwrap() error {
+	return e.kind
+}
+
+// Status 获取错误的分类
+//
+// Returns:
+//   - codes.Code: 无法识别的错误为codes.Unknown
+func (e *RpcError) Status() codes.Code {
+	return errs.Status(e.kind)
+}
+
+// 内置的错误码只有JSON-RPC 2.0规范定义的错误码。节点特有的错误码(余额不足、高度冲突、合约不存在等)没有内置，
+// SDK没有可以核对的节点错误输出，需要按节点实际返回的错误码通过 RegisterRpcErrorCode 注册
+var (
+	rpcErrorMappingMu   sync.RWMutex
+	rpcErrorCodeMapping = map[int16]error{
+		rpcParseErrorCode:     errs.ErrInvalidParams,
+		rpcInvalidRequestCode: errs.ErrInvalidParams,
+		rpcMethodNotFoundCode: errs.ErrMethodNotFound,
+		rpcInvalidParamsCode:  errs.ErrInvalidParams,
+	}
+	// 节点对不同的错误使用同一个错误码时，按错误码下错误信息中的短语识别，只能注册在节点实际输出中见过的错误码和短语
+	rpcErrorMessageMapping = map[int16][]rpcErrorPhrase{}
+)
+
+type rpcErrorPhrase struct {
+	phrase string
+	kind   error
+}
+
+// RegisterRpcErrorCode 注册节点错误码对应的错误类型，优先于按错误信息识别，注册对进程内所有的客户端生效
+//
+// Parameters:
+//   - code int16: 节点的错误码
+//   - kind error: 错误类型，例如 errs.ErrHeightConflict
+func RegisterRpcErrorCode(code int16, kind error) {
+	rpcErrorMappingMu.Lock()
+	defer rpcErrorMappingMu.Unlock()
+	rpcErrorCodeMapping[code] = kind
+}
+
+// RegisterRpcErrorMessage 节点对多种错误返回同一个错误码时，注册该错误码下错误信息中的短语对应的错误类型，
+// 短语需要取自节点实际返回的错误信息，不区分大小写，按注册的顺序匹配，注册对进程内所有的客户端生效
+//
+// Parameters:
+//   - code int16: 节点的错误码
+//   - phrase string: 错误信息中的短语
+//   - kind error: 错误类型，例如 errs.ErrInsufficientBalance
+func RegisterRpcErrorMessage(code int16, phrase string, kind error) {
+	rpcErrorMappingMu.Lock()
+	defer rpcErrorMappingMu.Unlock()
+	rpcErrorMessageMapping[code] = append(rpcErrorMessageMapping[code], rpcErrorPhrase{phrase: strings.ToLower(phrase), kind: kind})
+}
+
+// NewRpcError 根据节点返回的错误码和错误信息构造错误，并识别错误类型
+//
+// Parameters:
+//   - code int16: 节点的错误码
+//   - message string: 节点的错误信息
+//
+// Returns:
+//   - *RpcError
+func NewRpcError(code int16, message string) *RpcError {
+	return &RpcError{Code: code, Message: message, kind: classifyRpcError(code, message)}
+}
+
+// 先按错误码识别，错误码未注册时再按该错误码下注册的短语识别
+func classifyRpcError(code int16, message string) error {
+	rpcErrorMappingMu.RLock()
+	defer rpcErrorMappingMu.RUnlock()
+	if kind, ok := rpcErrorCodeMapping[code]; ok {
+		return kind
+	}
+	message = strings.ToLower(message)
+	for _, mapping := range rpcErrorMessageMapping[code] {
+		if strings.Contains(message, mapping.phrase) {
+			return mapping.kind
+		}
+	}
+	return nil
+}
+
+// 将请求超时的网络错误包装为 errs.ErrTimeout，原始错误仍可通过 errors.Is 匹配
+func wrapTransportError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", errs.ErrTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/codes"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 注册测试使用的错误码，结束后注销
+func registerTestRpcErrors(t *testing.T, register func()) {
+	rpcErrorMappingMu.RLock()
+	codeMapping := maps.Clone(rpcErrorCodeMapping)
+	messageMapping := maps.Clone(rpcErrorMessageMapping)
+	rpcErrorMappingMu.RUnlock()
+	register()
+	t.Cleanup(func() {
+		rpcErrorMappingMu.Lock()
+		defer rpcErrorMappingMu.Unlock()
+		rpcErrorCodeMapping, rpcErrorMessageMapping = codeMapping, messageMapping
+	})
+}
+
+// 返回JSON-RPC错误响应的节点
+func newRpcErrorTestServer(payload string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(payload))
+	}))
+}
+
+func postRpcError(t *testing.T, code int16, message string) error {
+	payload, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "error": map[string]interface{}{"code": code, "message": message}})
+	assert.NoError(t, err)
+	server := newRpcErrorTestServer(string(payload))
+	defer server.Close()
+	_, err = NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL}).GetNodeVersion(context.Background())
+	return err
+}
+
+func TestJsonRpcError_Error(t *testing.T) {
+	// JSON-RPC规范的错误码
+	err := postRpcError(t, rpcMethodNotFoundCode, "the method latc_xxx does not exist/is not available")
+	assert.ErrorIs(t, err, errs.ErrMethodNotFound)
+	var rpcErr *RpcError
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, rpcMethodNotFoundCode, rpcErr.Code)
+	assert.ErrorIs(t, postRpcError(t, rpcInvalidParamsCode, "invalid argument 0"), errs.ErrInvalidParams)
+
+	// 未注册的节点错误不按错误信息猜测
+	for _, message := range []string{"insufficient balance", "invalid height or parent hash", "合约不存在"} {
+		assert.Equal(t, codes.Unknown, errs.Status(postRpcError(t, -32000, message)), message)
+	}
+
+	registerTestRpcErrors(t, func() {
+		RegisterRpcErrorCode(-31001, errs.ErrHeightConflict)
+		RegisterRpcErrorMessage(-32000, "Insufficient Balance", errs.ErrInsufficientBalance)
+	})
+	err = postRpcError(t, -31001, "insufficient balance")
+	assert.ErrorIs(t, err, errs.ErrHeightConflict, "错误码优先于错误信息")
+	assert.Equal(t, codes.Aborted, errs.Status(err))
+	err = postRpcError(t, -32000, "insufficient balance, account: zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi")
+	assert.ErrorIs(t, err, errs.ErrInsufficientBalance)
+	assert.Equal(t, codes.FailedPrecondition, errs.Status(err))
+	// 短语只在注册的错误码下生效
+	assert.Equal(t, codes.Unknown, errs.Status(postRpcError(t, -32001, "insufficient balance")))
+}
+
+func TestPost_TypedTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerAuthorize) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL}).GetNodeVersion(context.Background())
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, JwtSecret: "secret"}).GetNodeVersion(ctx)
+	assert.ErrorIs(t, err, errs.ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -3,11 +3,10 @@ package client
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/rs/zerolog/log"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"github.com/wylu1037/lattice-go/common/logger"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/lattice/block"
 	"github.com/wylu1037/lattice-go/wallet"
@@ -18,7 +17,11 @@ import (
 	"time"
 )
 
-const emptyChainId = ""
+const (
+	emptyChainId = ""
+	// 批量请求时单次http请求包含的最大Json-Rpc请求数
+	maxBatchSize = 100
+)
 
 // JsonRpcBody Json-Rpc的请求体结构
 type JsonRpcBody struct {
@@ -41,8 +44,28 @@ type JsonRpcError struct {
 	Message string `json:"message,omitempty"`
 }
 
+// Error 转换为 *RpcError，可以通过 errors.Is 匹配 errs 包中的错误类型
 func (e *JsonRpcError) Error() error {
-	return fmt.Errorf("%d:%s", e.Code, e.Message)
+	return NewRpcError(e.Code, e.Message)
+}
+
+// BatchError 批量请求中部分请求失败，Errors 与请求一一对应，请求成功时对应位置为nil
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return fmt.Sprintf("%d of %d batch requests failed, first error: %v", failed, len(e.Errors), first)
 }
 
 func NewJsonRpcBody(method string, params ...interface{}) *JsonRpcBody {
@@ -54,109 +77,6 @@ func NewJsonRpcBody(method string, params ...interface{}) *JsonRpcBody {
 	}
 }
 
-func NewJwt(secret string, expirationDuration time.Duration) Jwt {
-	if secret == "" {
-		return nil
-	}
-	return &jwtImpl{
-		Secret:             secret,
-		Algorithm:          jwt.SigningMethodHS256,
-		ExpirationDuration: expirationDuration,
-		TokenCache:         new(JwtTokenCache),
-	}
-}
-
-// JwtTokenCache jwt token的缓存
-type JwtTokenCache struct {
-	Token    string
-	ExpireAt time.Time
-}
-
-// IsValid 验证Token是否有效
-//
-// Returns:
-//   - error
-func (cache *JwtTokenCache) IsValid() error {
-	if cache.Token == "" {
-		return errors.New("token is empty")
-	}
-
-	if time.Now().After(cache.ExpireAt) {
-		return errors.New("token is expired")
-	}
-
-	return nil
-}
-
-type Jwt interface {
-	GenerateToken() (string, error)
-	ParseToken(token string) (*jwt.Token, error)
-	GetToken() (string, error)
-}
-
-type jwtImpl struct {
-	Secret             string            // jwt的secret
-	Algorithm          jwt.SigningMethod // jwt.SigningMethodHS256
-	ExpirationDuration time.Duration     // token过期时长
-	TokenCache         *JwtTokenCache    // token缓存
-}
-
-func (j *jwtImpl) GenerateToken() (string, error) {
-	now := time.Now()
-	expiresAt := now.Add(j.ExpirationDuration).Add(-3 * time.Minute) // 提前3分钟过期
-	t := jwt.NewWithClaims(j.Algorithm, jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(expiresAt), // 该项验证
-		IssuedAt:  jwt.NewNumericDate(now),       // 该项验证
-		NotBefore: jwt.NewNumericDate(now),
-		Issuer:    "lattice_go",
-		Subject:   "jwt",
-		ID:        "1",
-		Audience:  []string{"somebody_else"},
-	})
-	token, err := t.SignedString([]byte(j.Secret))
-	if err != nil {
-		return "", err
-	}
-
-	j.TokenCache.Token = token
-	j.TokenCache.ExpireAt = expiresAt
-
-	return token, nil
-}
-
-func (j *jwtImpl) ParseToken(token string) (*jwt.Token, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte("AllYourBase"), nil
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	switch {
-	case t.Valid:
-		return t, nil
-	case errors.Is(err, jwt.ErrTokenMalformed):
-		return nil, errors.New("that's not even a token")
-	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
-		return nil, errors.New("invalid signature")
-	case errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet):
-		return nil, errors.New("token is either expired or not active yet")
-	default:
-		return nil, errors.New("couldn't handle this token")
-	}
-}
-
-func (j *jwtImpl) GetToken() (string, error) {
-	if err := j.TokenCache.IsValid(); err != nil {
-		token, err := j.GenerateToken()
-		if err != nil {
-			return "", err
-		}
-		return token, nil
-	}
-	return j.TokenCache.Token, nil
-}
-
 // HttpApiInitParam 初始化HTTP API的参数
 type HttpApiInitParam struct {
 	HttpUrl                    string            // 节点的URL
@@ -164,22 +84,64 @@ type HttpApiInitParam struct {
 	Transport                  http.RoundTripper // tr
 	JwtSecret                  string            // jwt的secret信息
 	JwtTokenExpirationDuration time.Duration     // jwt token的过期时间
+	TokenProvider              TokenProvider     // 不为空时代替JwtSecret，用于自定义签名算法、claims或外部签发的token
+	Nodes                      []*NodeEndpoint   // 其它节点，不为空时请求会按照RoutingPolicy在HttpUrl和这些节点间路由，并在网络错误时切换节点
+	RoutingPolicy              RoutingPolicy     // 多节点的路由策略，默认为 RoutingPolicyStickyPerAccount
+	HealthCheckInterval        time.Duration     // 多节点的健康检查间隔，默认10s
+	Logger                     logger.Logger     // 该实例的日志，为nil时使用 logger.SetLogger 设置的进程级日志
 }
 
+// NewHttpApi 初始化HttpApi，节点池初始化失败时panic，需要处理错误时使用 NewHttpApiWithError
 func NewHttpApi(args *HttpApiInitParam) HttpApi {
+	api, err := NewHttpApiWithError(args)
+	if err != nil {
+		panic(err)
+	}
+	return api
+}
+
+// NewHttpApiWithError 初始化HttpApi
+//
+// Parameters:
+//   - args *HttpApiInitParam
+//
+// Returns:
+//   - HttpApi
+//   - error: 节点的地址无效等原因导致节点池初始化失败时返回
+func NewHttpApiWithError(args *HttpApiInitParam) (HttpApi, error) {
 	if args.Transport == nil {
 		args.Transport = http.DefaultTransport
 	}
-	return &httpApi{
+	api := &httpApi{
 		Url:          args.HttpUrl,
 		GinServerUrl: args.GinServerUrl,
-		transport:    args.Transport,
-		jwtApi:       NewJwt(args.JwtSecret, args.JwtTokenExpirationDuration),
+		transport:    newAuthTransport(args.Transport, newTokenProvider(args.TokenProvider, args.JwtSecret, args.JwtTokenExpirationDuration)),
+		logger:       logger.NewHelper(args.Logger),
+	}
+	if len(args.Nodes) > 0 {
+		endpoints := append([]*NodeEndpoint{{HttpUrl: args.HttpUrl, GinServerUrl: args.GinServerUrl}}, args.Nodes...)
+		pool, err := newNodePool(endpoints, args.RoutingPolicy, args.HealthCheckInterval, api.transport, api.logger)
+		if err != nil {
+			api.logger.Error("Failed to initialize the node pool", "初始化节点池失败", logger.Err(err))
+			return nil, err
+		}
+		api.pool = pool
+		api.transport = pool
 	}
+	return api, nil
 }
 
 type HttpApi interface {
 
+	// NodeStatuses 获取各个节点的健康状态，未配置多节点时返回nil
+	//
+	// Returns:
+	//   - []*NodeStatus
+	NodeStatuses() []*NodeStatus
+
+	// Close 停止多节点的健康检查
+	Close()
+
 	// GetLatestBlock 获取当前账户的最新的区块信息，不包括pending中的交易
 	//
 	// Parameters:
@@ -215,6 +177,18 @@ type HttpApi interface {
 	//    - o
 	SendSignedTransaction(ctx context.Context, chainId string, signedTX *block.Transaction) (*common.Hash, error)
 
+	// SendSignedTransactions 在一个批量请求中按顺序发送多笔已签名的交易
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//   - signedTXs []*block.Transaction
+	//
+	// Returns:
+	//   - []*common.Hash: 与signedTXs一一对应，发送失败时对应位置为nil
+	//   - error: 部分发送失败时为 *BatchError
+	SendSignedTransactions(ctx context.Context, chainId string, signedTXs []*block.Transaction) ([]*common.Hash, error)
+
 	// PreCallContract 预执行合约
 	//
 	// Parameters:
@@ -252,28 +226,57 @@ type HttpApi interface {
 	//    - error
 	GetContractLifecycleProposal(ctx context.Context, chainId, contractAddress string, state types.ProposalState) ([]types.Proposal[types.ContractLifecycleProposal], error)
 
-	// UploadFile 上传文件到链上
+	// UploadFile 上传文件到链上，文件以流的方式发送，不会整个读入内存
 	//
 	// Parameters:
 	//   - ctx context.Context
 	//   - chainId string: 链ID
 	//   - filePath string: 文件路径
+	//   - opts ...FileTransferOption: 如 WithProgress
+	//
+	// Returns:
+	//   - *types.UploadFileResponse
+	//   - error
+	UploadFile(ctx context.Context, chainId, filePath string, opts ...FileTransferOption) (*types.UploadFileResponse, error)
+
+	// UploadFileFromReader 从reader中读取文件内容上传到链上
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string: 链ID
+	//   - fileName string: 文件名
+	//   - reader io.Reader: 文件内容
+	//   - opts ...FileTransferOption: 如 WithProgress、WithFileSize
 	//
 	// Returns:
 	//   - *types.UploadFileResponse
 	//   - error
-	UploadFile(ctx context.Context, chainId, filePath string) (*types.UploadFileResponse, error)
+	UploadFileFromReader(ctx context.Context, chainId, fileName string, reader io.Reader, opts ...FileTransferOption) (*types.UploadFileResponse, error)
 
-	// DownloadFile 从链上下载文件
+	// DownloadFile 从链上下载文件，下载失败时保留已下载的部分，可以通过 WithResume 续传
 	//
 	// Parameters:
 	//   - ctx context.Context
 	//   - cid string: 要下载文件的cid
 	//   - filePath string: 指定的临时存储路径
+	//   - opts ...FileTransferOption: 如 WithProgress、WithResume、WithCIDVerification
 	//
 	// Returns:
-	//   - error
-	DownloadFile(ctx context.Context, cid, filePath string) error
+	//   - error: 内容与cid不一致时为 ErrCIDMismatch
+	DownloadFile(ctx context.Context, cid, filePath string, opts ...FileTransferOption) error
+
+	// DownloadFileToWriter 从链上下载文件写入writer
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - cid string: 要下载文件的cid
+	//   - writer io.Writer
+	//   - opts ...FileTransferOption: 如 WithProgress、WithOffset、WithCIDVerification，WithOffset 不能与 WithCIDVerification 同时使用
+	//
+	// Returns:
+	//   - int64: 本次写入的字节数
+	//   - error: 节点不支持续传时为 ErrRangeNotSupported
+	DownloadFileToWriter(ctx context.Context, cid string, writer io.Writer, opts ...FileTransferOption) (int64, error)
 
 	// GetNodeInfo 获取节点信息
 	//
@@ -510,13 +513,56 @@ type HttpApi interface {
 	GetSnapshot(ctx context.Context, chainId string, daemonBlockHeight *big.Int) (*types.NodeProtocolConfig, error)
 	GetLatcInfo(ctx context.Context, chainId string) (*types.NodeProtocolConfig, error)
 	GetProposalById(ctx context.Context, chainId, proposalId string, result interface{}) error
+
+	// GetReceipts 批量获取交易回执，按批次合并为少量的http请求
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//   - hashes []string: 交易哈希
+	//
+	// Returns:
+	//   - []*types.Receipt: 与hashes一一对应，查询失败时对应位置为nil
+	//   - error: 部分查询失败时为 *BatchError
+	GetReceipts(ctx context.Context, chainId string, hashes []string) ([]*types.Receipt, error)
+
+	// GetTransactionBlocksByHash 根据哈希批量查询交易区块，按批次合并为少量的http请求
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//   - hashes []string: 交易哈希
+	//
+	// Returns:
+	//   - []*types.TransactionBlock: 与hashes一一对应，查询失败时对应位置为nil
+	//   - error: 部分查询失败时为 *BatchError
+	GetTransactionBlocksByHash(ctx context.Context, chainId string, hashes []string) ([]*types.TransactionBlock, error)
 }
 
 type httpApi struct {
 	Url          string            // 节点的Http请求路径
 	GinServerUrl string            // 节点的Gin服务请求路径
-	transport    http.RoundTripper // http transport
-	jwtApi       Jwt               // jwt api
+	transport    http.RoundTripper // http transport，配置了token时会设置 Authorization 请求头
+	pool         *nodePool         // 多节点时的节点池，为nil时所有请求发送到Url
+	logger       *logger.Helper    // 该实例的日志
+}
+
+// 将实例的日志绑定到ctx，Post、BatchPost 和节点池从ctx获取日志
+func (api *httpApi) withLogger(ctx context.Context) context.Context {
+	return logger.NewContext(ctx, api.logger)
+}
+
+func (api *httpApi) NodeStatuses() []*NodeStatus {
+	if api.pool == nil {
+		return nil
+	}
+	return api.pool.statuses()
+}
+
+func (api *httpApi) Close() {
+	if api.pool != nil {
+		api.pool.close()
+	}
 }
 
 const (
@@ -538,15 +584,11 @@ func (api *httpApi) newHeaders(chainId string) map[string]string {
 		headerContentType: "application/json",
 		headerChainID:     chainId,
 	}
-	if api.jwtApi != nil {
-		token, _ := api.jwtApi.GetToken()
-		headers[headerAuthorize] = fmt.Sprintf("Bearer %s", token)
-	}
 	return headers
 }
 
 func (api *httpApi) SendSignedTransaction(ctx context.Context, chainId string, signedTX *block.Transaction) (*common.Hash, error) {
-	response, err := Post[common.Hash](ctx, api.Url, NewJsonRpcBody("wallet_sendRawTBlock", signedTX), api.newHeaders(chainId), api.transport)
+	response, err := Post[common.Hash](withoutFailover(api.withLogger(ctx)), api.Url, NewJsonRpcBody("wallet_sendRawTBlock", signedTX), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -556,8 +598,12 @@ func (api *httpApi) SendSignedTransaction(ctx context.Context, chainId string, s
 	return response.Result, nil
 }
 
+func (api *httpApi) SendSignedTransactions(ctx context.Context, chainId string, signedTXs []*block.Transaction) ([]*common.Hash, error) {
+	return batchCall[common.Hash](withoutFailover(ctx), api, chainId, "wallet_sendRawTBlock", signedTXs)
+}
+
 func (api *httpApi) PreCallContract(ctx context.Context, chainId string, unsignedTX *block.Transaction) (*types.Receipt, error) {
-	response, err := Post[types.Receipt](ctx, api.Url, NewJsonRpcBody("wallet_preExecuteContract", unsignedTX), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.Receipt](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_preExecuteContract", unsignedTX), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -568,7 +614,7 @@ func (api *httpApi) PreCallContract(ctx context.Context, chainId string, unsigne
 }
 
 func (api *httpApi) GetReceipt(ctx context.Context, chainId, hash string) (*types.Receipt, error) {
-	response, err := Post[types.Receipt](ctx, api.Url, NewJsonRpcBody("latc_getReceipt", hash), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.Receipt](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getReceipt", hash), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -578,8 +624,12 @@ func (api *httpApi) GetReceipt(ctx context.Context, chainId, hash string) (*type
 	return response.Result, nil
 }
 
+func (api *httpApi) GetReceipts(ctx context.Context, chainId string, hashes []string) ([]*types.Receipt, error) {
+	return batchCall[types.Receipt](ctx, api, chainId, "latc_getReceipt", hashes)
+}
+
 func (api *httpApi) ExistsBusinessContractAddress(ctx context.Context, chainId, address string) (bool, error) {
-	response, err := Post[bool](ctx, api.Url, NewJsonRpcBody("wallet_confirmTaggedContract", address), api.newHeaders(chainId), api.transport)
+	response, err := Post[bool](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_confirmTaggedContract", address), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return false, nil
 	}
@@ -590,7 +640,7 @@ func (api *httpApi) ExistsBusinessContractAddress(ctx context.Context, chainId,
 }
 
 func (api *httpApi) GetEvidences(ctx context.Context, chainId, date string, evidenceType types.EvidenceType, page, pageSize int) (*types.Evidences, error) {
-	response, err := Post[types.Evidences](ctx, api.Url, NewJsonRpcBody("latc_getEvidences", date, evidenceType, page, pageSize), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.Evidences](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getEvidences", date, evidenceType, page, pageSize), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -601,7 +651,7 @@ func (api *httpApi) GetEvidences(ctx context.Context, chainId, date string, evid
 }
 
 func (api *httpApi) GetErrorEvidences(ctx context.Context, chainId, date string, evidenceLevel types.EvidenceLevel, evidenceType types.EvidenceType, page, pageSize int) (*types.Evidences, error) {
-	response, err := Post[types.Evidences](ctx, api.Url, NewJsonRpcBody("latc_getErrorEvidences", date, evidenceLevel, evidenceType, page, pageSize), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.Evidences](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getErrorEvidences", date, evidenceLevel, evidenceType, page, pageSize), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -631,15 +681,104 @@ func Post[T any](ctx context.Context, url string, jsonRpcBody *JsonRpcBody, head
 
 	var t JsonRpcResponse[*T]
 	if err := json.Unmarshal(response, &t); err != nil {
-		log.Error().Err(err).Msg("Failed to unmarshal response body")
+		logger.FromContext(ctx).Error("Failed to unmarshal response body", "解析响应体失败", logger.Err(err))
 		return nil, err
 	}
 
 	return &t, nil
 }
 
-func rawPost(ctx context.Context, url string, jsonRpcBody *JsonRpcBody, headers map[string]string, tr http.RoundTripper) ([]byte, error) {
-	log.Debug().Msgf("开始发送JsonRpc请求，url: %s, body: %+v", url, jsonRpcBody)
+// BatchPost send a batch of json-rpc requests in one http request
+//
+// Parameters:
+//   - ctx context.Context: 超时取消
+//   - url string: 请求路径，示例：http://192.168.1.20:13000
+//   - jsonRpcBodies []*JsonRpcBody: 请求体，请求的Id会按顺序重新编号
+//   - headers map[string]string: 请求头
+//   - tr http.Transport:
+//
+// Returns:
+//   - []*JsonRpcResponse[*T]: 按请求的顺序返回的响应
+//   - error: 错误
+func BatchPost[T any](ctx context.Context, url string, jsonRpcBodies []*JsonRpcBody, headers map[string]string, tr http.RoundTripper) ([]*JsonRpcResponse[*T], error) {
+	if len(jsonRpcBodies) == 0 {
+		return nil, nil
+	}
+	bodies := make([]*JsonRpcBody, len(jsonRpcBodies))
+	for i, body := range jsonRpcBodies {
+		bodies[i] = &JsonRpcBody{Id: i + 1, JsonRpc: body.JsonRpc, Method: body.Method, Params: body.Params}
+	}
+
+	response, err := rawPost(ctx, url, bodies, headers, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*JsonRpcResponse[*T]
+	if err := json.Unmarshal(response, &responses); err != nil {
+		// the node answers a rejected batch with a single response object
+		var single JsonRpcResponse[*T]
+		if json.Unmarshal(response, &single) == nil && single.Error != nil {
+			return nil, single.Error.Error()
+		}
+		logger.FromContext(ctx).Error("Failed to unmarshal batch response body", "解析批量请求的响应体失败", logger.Err(err))
+		return nil, err
+	}
+
+	results := make([]*JsonRpcResponse[*T], len(bodies))
+	for _, r := range responses {
+		if r == nil || r.Id < 1 || r.Id > len(bodies) || results[r.Id-1] != nil {
+			return nil, fmt.Errorf("unexpected id in batch response: %v", r)
+		}
+		results[r.Id-1] = r
+	}
+	for i, r := range results {
+		if r == nil {
+			return nil, fmt.Errorf("missing response for batch request %d, method: %s", i, bodies[i].Method)
+		}
+	}
+	return results, nil
+}
+
+// 批量调用只有一个参数的方法，按 maxBatchSize 拆分为多个http请求，
+// 某个批次的http请求失败时保留已获取的结果，该批次及之后的请求在 *BatchError 中标记为该错误
+func batchCall[T any, P any](ctx context.Context, api *httpApi, chainId, method string, params []P) ([]*T, error) {
+	results := make([]*T, len(params))
+	errList := make([]error, len(params))
+	failed := false
+	for start := 0; start < len(params); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(params))
+		bodies := make([]*JsonRpcBody, 0, end-start)
+		for _, param := range params[start:end] {
+			bodies = append(bodies, NewJsonRpcBody(method, param))
+		}
+		responses, err := BatchPost[T](api.withLogger(ctx), api.Url, bodies, api.newHeaders(chainId), api.transport)
+		if err != nil {
+			// 保留之前批次的结果，当前及之后未发送的请求都标记为该错误
+			for i := start; i < len(params); i++ {
+				errList[i] = err
+			}
+			return results, &BatchError{Errors: errList}
+		}
+		for i, response := range responses {
+			if response.Error != nil {
+				errList[start+i] = response.Error.Error()
+				failed = true
+				continue
+			}
+			results[start+i] = response.Result
+		}
+	}
+	if failed {
+		return results, &BatchError{Errors: errList}
+	}
+	return results, nil
+}
+
+func rawPost(ctx context.Context, url string, jsonRpcBody interface{}, headers map[string]string, tr http.RoundTripper) ([]byte, error) {
+	ctx = withRpcMethod(ctx, jsonRpcBody)
+	log := logger.FromContext(ctx)
+	log.Debug("Start sending the JsonRpc request", "开始发送JsonRpc请求", logger.String("url", url), logger.String("method", rpcMethodFromContext(ctx)))
 	bodyBytes, err := json.Marshal(jsonRpcBody)
 	if err != nil {
 		return nil, err
@@ -648,7 +787,7 @@ func rawPost(ctx context.Context, url string, jsonRpcBody *JsonRpcBody, headers
 
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create http request")
+		log.Error("Failed to create http request", "创建http请求失败", logger.Err(err))
 		return nil, err
 	}
 
@@ -661,17 +800,21 @@ func rawPost(ctx context.Context, url string, jsonRpcBody *JsonRpcBody, headers
 	request.TransferEncoding = []string{}
 	response, err := client.Do(request)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to send http request")
-		return nil, err
+		log.Error("Failed to send http request", "发送http请求失败", logger.String("url", url), logger.Err(err))
+		return nil, wrapTransportError(err)
 	}
 	defer func(Body io.ReadCloser) {
 		if err := Body.Close(); err != nil {
-			log.Error().Err(err).Msg("Failed to close response body")
+			log.Error("Failed to close response body", "关闭响应体失败", logger.Err(err))
 		}
 	}(response.Body)
 
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w: http status %d", errs.ErrUnauthorized, response.StatusCode)
+	}
+
 	if res, err := io.ReadAll(response.Body); err != nil {
-		log.Error().Err(err).Msg("Failed to read response body")
+		log.Error("Failed to read response body", "读取响应体失败", logger.Err(err))
 		return nil, err
 	} else {
 		return res, nil
@@ -6,7 +6,7 @@ import (
 )
 
 func (api *httpApi) GetLatestBlock(ctx context.Context, chainId, accountAddress string) (*types.LatestBlock, error) {
-	response, err := Post[types.LatestBlock](ctx, api.Url, NewJsonRpcBody("latc_getCurrentTBDB", accountAddress), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.LatestBlock](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getCurrentTBDB", accountAddress), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +17,7 @@ func (api *httpApi) GetLatestBlock(ctx context.Context, chainId, accountAddress
 }
 
 func (api *httpApi) GetLatestBlockWithPending(ctx context.Context, chainId, accountAddress string) (*types.LatestBlock, error) {
-	response, err := Post[types.LatestBlock](ctx, api.Url, NewJsonRpcBody("latc_getPendingTBDB", accountAddress), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.LatestBlock](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getPendingTBDB", accountAddress), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +28,7 @@ func (api *httpApi) GetLatestBlockWithPending(ctx context.Context, chainId, acco
 }
 
 func (api *httpApi) GetLatestDaemonBlock(ctx context.Context, chainID string) (*types.DaemonBlock, error) {
-	response, err := Post[types.DaemonBlock](ctx, api.Url, NewJsonRpcBody("latc_getCurrentDBlock"), api.newHeaders(chainID), api.transport)
+	response, err := Post[types.DaemonBlock](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getCurrentDBlock"), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +39,7 @@ func (api *httpApi) GetLatestDaemonBlock(ctx context.Context, chainID string) (*
 }
 
 func (api *httpApi) GetDaemonBlockByHash(ctx context.Context, chainId, hash string) (*types.DaemonBlock, error) {
-	response, err := Post[types.DaemonBlock](ctx, api.Url, NewJsonRpcBody("latc_getDBlockByHash", hash), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.DaemonBlock](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getDBlockByHash", hash), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +50,7 @@ func (api *httpApi) GetDaemonBlockByHash(ctx context.Context, chainId, hash stri
 }
 
 func (api *httpApi) GetTransactionBlockByHash(ctx context.Context, chainId, hash string) (*types.TransactionBlock, error) {
-	response, err := Post[types.TransactionBlock](ctx, api.Url, NewJsonRpcBody("latc_getTBlockByHash", hash), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.TransactionBlock](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getTBlockByHash", hash), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +60,12 @@ func (api *httpApi) GetTransactionBlockByHash(ctx context.Context, chainId, hash
 	return response.Result, nil
 }
 
+func (api *httpApi) GetTransactionBlocksByHash(ctx context.Context, chainId string, hashes []string) ([]*types.TransactionBlock, error) {
+	return batchCall[types.TransactionBlock](ctx, api, chainId, "latc_getTBlockByHash", hashes)
+}
+
 func (api *httpApi) GetTransactionsPagination(ctx context.Context, chainId string, startDaemonBlockHeight uint64, pageSize uint16) (*types.TransactionsPagination, error) {
-	response, err := Post[types.TransactionsPagination](ctx, api.Url, NewJsonRpcBody("latc_getTBlockPagesByDNumber", startDaemonBlockHeight, pageSize), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.TransactionsPagination](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getTBlockPagesByDNumber", startDaemonBlockHeight, pageSize), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
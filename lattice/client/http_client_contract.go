@@ -7,7 +7,7 @@ import (
 )
 
 func (api *httpApi) GetContractInformation(ctx context.Context, chainID, contractAddress string) (*types.ContractInformation, error) {
-	response, err := Post[types.ContractInformation](ctx, api.Url, NewJsonRpcBody("wallet_getContractState", contractAddress), api.newHeaders(chainID), api.transport)
+	response, err := Post[types.ContractInformation](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_getContractState", contractAddress), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -21,9 +21,9 @@ func (api *httpApi) GetContractManagement(ctx context.Context, chainID, contract
 	var err error
 	var response *JsonRpcResponse[*types.ContractManagement]
 	if daemonBlockHeight == nil {
-		response, err = Post[types.ContractManagement](ctx, api.Url, NewJsonRpcBody("wallet_getPermissionList", contractAddress), api.newHeaders(chainID), api.transport)
+		response, err = Post[types.ContractManagement](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_getPermissionList", contractAddress), api.newHeaders(chainID), api.transport)
 	} else {
-		response, err = Post[types.ContractManagement](ctx, api.Url, NewJsonRpcBody("wallet_getPermissionList", contractAddress, daemonBlockHeight), api.newHeaders(chainID), api.transport)
+		response, err = Post[types.ContractManagement](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_getPermissionList", contractAddress, daemonBlockHeight), api.newHeaders(chainID), api.transport)
 	}
 	if err != nil {
 		return nil, err
@@ -1,130 +1,353 @@
 package client
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/rs/zerolog/log"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/wylu1037/lattice-go/common/logger"
 	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"hash"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
-func (api *httpApi) UploadFile(ctx context.Context, chainId, filePath string) (*types.UploadFileResponse, error) {
-	log.Debug().Msgf("开始上传文件到链上，chainId: %s, filePath: %s", chainId, filePath)
-	uploadPath := fmt.Sprintf("%s/%s", api.GinServerUrl, "beforeSign")
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+var (
+	// ErrCIDMismatch 下载的文件内容与cid不一致
+	ErrCIDMismatch = errors.New("the downloaded content does not match the cid")
+	// ErrRangeNotSupported 文件服务不支持断点续传
+	ErrRangeNotSupported = errors.New("the file service does not support ranged downloads")
+)
+
+// ProgressFunc 文件传输的进度回调
+//
+// Parameters:
+//   - transferred int64: 已传输的字节数，续传时包括之前已下载的部分
+//   - total int64: 文件的总字节数，未知时为-1
+type ProgressFunc func(transferred, total int64)
+
+// FileTransferOption 文件上传、下载的可选项
+type FileTransferOption func(*fileTransferOptions)
+
+type fileTransferOptions struct {
+	progress ProgressFunc
+	size     int64 // 上传文件的大小，-1表示未知
+	resume   bool
+	offset   int64
+	newHash  func() hash.Hash // 不为nil时校验cid
+}
+
+func newFileTransferOptions(opts []FileTransferOption) *fileTransferOptions {
+	options := &fileTransferOptions{size: -1}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithProgress 设置进度回调，每次读写数据后都会调用
+func WithProgress(progress ProgressFunc) FileTransferOption {
+	return func(options *fileTransferOptions) {
+		options.progress = progress
+	}
+}
+
+// WithFileSize 设置上传文件的大小，用于 UploadFileFromReader 的进度回调，UploadFile 会自动获取
+func WithFileSize(size int64) FileTransferOption {
+	return func(options *fileTransferOptions) {
+		options.size = size
+	}
+}
+
+// WithResume DownloadFile 时从已存在文件的末尾继续下载
+func WithResume() FileTransferOption {
+	return func(options *fileTransferOptions) {
+		options.resume = true
+	}
+}
+
+// WithOffset DownloadFileToWriter 时从offset处继续下载，writer中已有前offset个字节
+func WithOffset(offset int64) FileTransferOption {
+	return func(options *fileTransferOptions) {
+		options.offset = offset
+	}
+}
+
+// CIDHash 获取文件服务计算cid使用的哈希算法，与链上使用的哈希算法一致
+//   - crypto.Secp256k1 sha256
+//   - crypto.Sm2p256v1 sm3
+//
+// Parameters:
+//   - curve types.Curve
+//
+// Returns:
+//   - func() hash.Hash
+//   - error: 不支持的曲线
+func CIDHash(curve types.Curve) (func() hash.Hash, error) {
+	switch curve {
+	case crypto.Secp256k1:
+		return sha256.New, nil
+	case crypto.Sm2p256v1:
+		return sm3.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve for the cid: %s", curve)
+	}
+}
+
+// WithCIDVerification 下载完成后校验内容与cid是否一致，cid为内容哈希的base58编码，不含multihash前缀
+//
+// Parameters:
+//   - newHash func() hash.Hash: 计算cid的哈希算法，需要与链的曲线一致，见 CIDHash，为nil时使用sha256
+func WithCIDVerification(newHash func() hash.Hash) FileTransferOption {
+	return func(options *fileTransferOptions) {
+		if newHash == nil {
+			newHash = sha256.New
+		}
+		options.newHash = newHash
+	}
+}
+
+// 统计读取的字节数并回调进度
+type progressReader struct {
+	reader      io.Reader
+	transferred int64
+	total       int64
+	progress    ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+		r.progress(r.transferred, r.total)
+	}
+	return n, err
+}
 
+func withProgress(reader io.Reader, transferred, total int64, progress ProgressFunc) io.Reader {
+	if progress == nil {
+		return reader
+	}
+	return &progressReader{reader: reader, transferred: transferred, total: total, progress: progress}
+}
+
+func (api *httpApi) UploadFile(ctx context.Context, chainId, filePath string, opts ...FileTransferOption) (*types.UploadFileResponse, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func(file *os.File) {
 		if err := file.Close(); err != nil {
-			log.Error().Err(err).Msg("failed to close file")
+			api.logger.Error("Failed to close file", "关闭文件失败", logger.Err(err))
 		}
 	}(file)
-
-	part, err := writer.CreateFormFile("file", filepath.Base(file.Name()))
+	info, err := file.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-	if _, err = io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file data: %w", err)
+		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close writer: %w", err)
-	}
+	return api.UploadFileFromReader(ctx, chainId, filepath.Base(file.Name()), file, append([]FileTransferOption{WithFileSize(info.Size())}, opts...)...)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadPath, body)
+func (api *httpApi) UploadFileFromReader(ctx context.Context, chainId, fileName string, reader io.Reader, opts ...FileTransferOption) (*types.UploadFileResponse, error) {
+	api.logger.Debug("Start uploading the file", "开始上传文件到链上", logger.String("chainId", chainId), logger.String("fileName", fileName))
+	options := newFileTransferOptions(opts)
+	uploadPath := fmt.Sprintf("%s/%s", api.GinServerUrl, "beforeSign")
+
+	// 边读边发送，不在内存中缓存文件
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadPath, pipeReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set(headerContentType, writer.FormDataContentType()) // fmt.Sprintf("multipart/form-data; boundary=%s", writer.Boundary())
+	req.Header.Set(headerContentType, writer.FormDataContentType())
 	req.Header.Set(headerChainID, chainId)
-	if api.jwtApi != nil {
-		token, _ := api.jwtApi.GetToken()
-		req.Header.Set(headerAuthorize, fmt.Sprintf("Bearer %s", token))
-	}
 
-	client := &http.Client{}
+	go func() {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err == nil {
+			_, err = io.Copy(part, withProgress(reader, 0, options.size, options.progress))
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		_ = pipeWriter.CloseWithError(err)
+	}()
+
+	client := &http.Client{Transport: api.transport}
 	resp, err := client.Do(req)
+	// 请求失败时停止读取文件
+	_ = pipeReader.Close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to do request: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		if err := Body.Close(); err != nil {
-			log.Error().Err(err).Msg("failed to close response body")
+			api.logger.Error("Failed to close response body", "关闭响应体失败", logger.Err(err))
 		}
 	}(resp.Body)
-
-	responseData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("上传文件【%s】失败，Http状态码为: %d", fileName, resp.StatusCode)
 	}
 
 	uploadFileResponse := new(types.UploadFileResponse)
-	if err := json.Unmarshal(responseData, uploadFileResponse); err != nil {
-		log.Error().Err(err).Msg("Failed to unmarshal response body")
+	if err := json.NewDecoder(resp.Body).Decode(uploadFileResponse); err != nil {
+		api.logger.Error("Failed to unmarshal response body", "解析响应体失败", logger.Err(err))
 		return nil, err
 	}
-	log.Debug().Msgf("结束上传文件【%s】到链上", filePath)
+	api.logger.Debug("Finish uploading the file", "结束上传文件到链上", logger.String("fileName", fileName), logger.String("cid", uploadFileResponse.CID))
 	return uploadFileResponse, nil
 }
 
-func (api *httpApi) DownloadFile(ctx context.Context, cid, filePath string) error {
-	log.Debug().Msgf("开始从链上下载文件【%s】", cid)
-	downloadUrl := fmt.Sprintf("%s/download?cid=%s", api.GinServerUrl, cid)
+func (api *httpApi) DownloadFile(ctx context.Context, cid, filePath string, opts ...FileTransferOption) error {
+	options := newFileTransferOptions(opts)
+	flag := os.O_CREATE | os.O_RDWR
+	if !options.resume {
+		flag |= os.O_TRUNC
+	}
+	outFile, err := os.OpenFile(filePath, flag, 0o644)
+	if err != nil {
+		api.logger.Error("Failed to create file", "创建文件失败", logger.String("filePath", filePath), logger.Err(err))
+		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			api.logger.Error("Failed to close file", "关闭文件失败", logger.Err(err))
+		}
+	}(outFile)
+
+	var hasher hash.Hash
+	if options.newHash != nil {
+		hasher = options.newHash()
+	}
+	// 续传时已下载的部分也需要参与cid的校验
+	offset, err := io.Copy(withHasher(io.Discard, hasher), outFile)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	_, err = api.download(ctx, cid, outFile, offset, hasher, options, func() error {
+		if hasher != nil {
+			hasher.Reset()
+		}
+		if err := outFile.Truncate(0); err != nil {
+			return err
+		}
+		_, err := outFile.Seek(0, io.SeekStart)
+		return err
+	})
+	return err
+}
+
+func (api *httpApi) DownloadFileToWriter(ctx context.Context, cid string, writer io.Writer, opts ...FileTransferOption) (int64, error) {
+	options := newFileTransferOptions(opts)
+	var hasher hash.Hash
+	if options.newHash != nil {
+		if options.offset > 0 {
+			return 0, errors.New("the cid can not be verified when downloading from an offset")
+		}
+		hasher = options.newHash()
+	}
+	return api.download(ctx, cid, writer, options.offset, hasher, options, func() error {
+		return ErrRangeNotSupported
+	})
+}
+
+// 从offset处下载文件写入writer，节点忽略Range请求返回完整文件时调用restart从头写入
+func (api *httpApi) download(ctx context.Context, cid string, writer io.Writer, offset int64, hasher hash.Hash, options *fileTransferOptions, restart func() error) (int64, error) {
+	api.logger.Debug("Start downloading the file", "开始从链上下载文件", logger.String("cid", cid), logger.Uint64("offset", uint64(offset)))
+	downloadUrl := fmt.Sprintf("%s/download?cid=%s", api.GinServerUrl, url.QueryEscape(cid))
 
 	downloadReq, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadUrl, nil)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create request for download")
-		return fmt.Errorf("failed to create request: %w", err)
+		api.logger.Error("Failed to create request for download", "创建下载请求失败", logger.Err(err))
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
-	if api.jwtApi != nil {
-		token, _ := api.jwtApi.GetToken()
-		downloadReq.Header.Set(headerAuthorize, fmt.Sprintf("Bearer %s", token))
-		downloadReq.Header.Set(headerContentType, "multipart/form-data; charset=UTF-8")
-		downloadReq.Header.Set(headerConnection, "close")
+	downloadReq.Header.Set(headerContentType, "multipart/form-data; charset=UTF-8")
+	downloadReq.Header.Set(headerConnection, "close")
+	if offset > 0 {
+		downloadReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	client := &http.Client{}
+	client := &http.Client{Transport: api.transport}
 	resp, err := client.Do(downloadReq)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to download file")
-		return fmt.Errorf("failed to download file: %w", err)
+		api.logger.Error("Failed to download file", "下载文件失败", logger.String("cid", cid), logger.Err(err))
+		return 0, fmt.Errorf("failed to download file: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		if err := Body.Close(); err != nil {
-			log.Error().Err(err).Msg("failed to close response body")
-
+			api.logger.Error("Failed to close response body", "关闭响应体失败", logger.Err(err))
 		}
 	}(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("下载文件【%s】失败，Http状态吗为: %d", cid, resp.StatusCode)
+
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 已经下载完成
+		return 0, verifyCID(cid, hasher)
+	case resp.StatusCode == http.StatusPartialContent:
+		total = contentRangeTotal(resp.Header.Get("Content-Range"))
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			if err := restart(); err != nil {
+				return 0, err
+			}
+			offset = 0
+		}
+	default:
+		return 0, fmt.Errorf("下载文件【%s】失败，Http状态码为: %d", cid, resp.StatusCode)
 	}
 
-	outFile, err := os.Create(filePath)
+	written, err := io.Copy(withHasher(writer, hasher), withProgress(resp.Body, offset, total, options.progress))
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create file")
-		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+		api.logger.Error("Failed to copy file data", "写入文件失败", logger.String("cid", cid), logger.Err(err))
+		return written, fmt.Errorf("failed to copy file data: %w", err)
 	}
-	defer func(file *os.File) {
-		if err := file.Close(); err != nil {
-			log.Error().Err(err).Msg("failed to close response body")
-		}
-	}(outFile)
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to copy file data")
+	if err := verifyCID(cid, hasher); err != nil {
+		return written, err
 	}
+	api.logger.Debug("Finish downloading the file", "结束从链上下载文件", logger.String("cid", cid))
+	return written, nil
+}
+
+func withHasher(writer io.Writer, hasher hash.Hash) io.Writer {
+	if hasher == nil {
+		return writer
+	}
+	return io.MultiWriter(writer, hasher)
+}
 
-	log.Debug().Msgf("结束从链上下载文件【%s】", cid)
+func verifyCID(cid string, hasher hash.Hash) error {
+	if hasher == nil {
+		return nil
+	}
+	if actual := base58.Encode(hasher.Sum(nil)); actual != cid {
+		return fmt.Errorf("%w: expected %s, got %s", ErrCIDMismatch, cid, actual)
+	}
 	return nil
 }
+
+// 解析 Content-Range: bytes 0-99/200 中的总长度，未知时返回-1
+func contentRangeTotal(contentRange string) int64 {
+	index := strings.LastIndex(contentRange, "/")
+	if index < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(contentRange[index+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 模拟gin的文件服务，rangeSupported为false时忽略Range请求
+func newFileTestServer(t *testing.T, content []byte, rangeSupported bool) *httptest.Server {
+	sum := sha256.Sum256(content)
+	cid := base58.Encode(sum[:])
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/beforeSign":
+			// 流式上传使用分块传输
+			assert.Equal(t, int64(-1), r.ContentLength)
+			file, header, err := r.FormFile("file")
+			if !assert.NoError(t, err) {
+				return
+			}
+			data, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			sum := sha256.Sum256(data)
+			_ = json.NewEncoder(w).Encode(&types.UploadFileResponse{CID: base58.Encode(sum[:]), FilePath: header.Filename, Message: "success"})
+		case "/download":
+			assert.Equal(t, cid, r.URL.Query().Get("cid"))
+			if rangeSupported {
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+				return
+			}
+			_, _ = w.Write(content)
+		}
+	}))
+}
+
+func TestCIDHash(t *testing.T) {
+	// 内容"abc"的cid，即标准的sha256、sm3摘要的base58编码
+	tests := []struct {
+		curve types.Curve
+		cid   string
+	}{
+		{crypto.Secp256k1, "DYu3G8aGTMBW1WrTw76zxQJQU4DHLw9MLyy7peG4LKkY"},
+		{crypto.Sm2p256v1, "7vDR2PzKbmChaKGiX4zDqNNwwsdHZcsb1HxAiUSoXUjZ"},
+	}
+	for _, tt := range tests {
+		newHash, err := CIDHash(tt.curve)
+		assert.NoError(t, err)
+		hasher := newHash()
+		hasher.Write([]byte("abc"))
+		assert.NoError(t, verifyCID(tt.cid, hasher), tt.curve)
+	}
+
+	// 文件服务返回的cid是32字节的摘要，不含multihash前缀
+	assert.Len(t, base58.Decode("GPK3PveRaWoK6S2b53D3ZeJTm4nBvv2vSVjRStRLQcyX"), 32)
+
+	_, err := CIDHash("ed25519")
+	assert.Error(t, err)
+}
+
+func TestHttpApi_UploadFile(t *testing.T) {
+	content := make([]byte, 1<<20)
+	_, _ = rand.Read(content)
+	server := newFileTestServer(t, content, true)
+	defer server.Close()
+	api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, GinServerUrl: server.URL})
+
+	filePath := filepath.Join(t.TempDir(), "data.bin")
+	assert.NoError(t, os.WriteFile(filePath, content, 0o644))
+	var transferred, total int64
+	response, err := api.UploadFile(context.Background(), "1", filePath, WithProgress(func(n, size int64) {
+		transferred, total = n, size
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "data.bin", response.FilePath)
+	assert.Equal(t, int64(len(content)), transferred)
+	assert.Equal(t, int64(len(content)), total)
+
+	fromReader, err := api.UploadFileFromReader(context.Background(), "1", "data.bin", bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, response.CID, fromReader.CID)
+}
+
+func TestHttpApi_DownloadFile(t *testing.T) {
+	content := make([]byte, 1<<20)
+	_, _ = rand.Read(content)
+	sum := sha256.Sum256(content)
+	cid := base58.Encode(sum[:])
+
+	t.Run("writer with cid verification", func(t *testing.T) {
+		server := newFileTestServer(t, content, true)
+		defer server.Close()
+		api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, GinServerUrl: server.URL})
+
+		var buf bytes.Buffer
+		n, err := api.DownloadFileToWriter(context.Background(), cid, &buf, WithCIDVerification(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.Equal(t, content, buf.Bytes())
+
+		// 从offset处续传
+		buf.Reset()
+		n, err = api.DownloadFileToWriter(context.Background(), cid, &buf, WithOffset(100))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)-100), n)
+		assert.Equal(t, content[100:], buf.Bytes())
+
+		// 内容与cid不一致
+		_, err = api.DownloadFileToWriter(context.Background(), cid, io.Discard, WithCIDVerification(sha256.New224))
+		assert.ErrorIs(t, err, ErrCIDMismatch)
+	})
+
+	t.Run("resume to file", func(t *testing.T) {
+		server := newFileTestServer(t, content, true)
+		defer server.Close()
+		api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, GinServerUrl: server.URL})
+
+		filePath := filepath.Join(t.TempDir(), "data.bin")
+		assert.NoError(t, os.WriteFile(filePath, content[:1000], 0o644))
+		var first, last int64 = -1, 0
+		err := api.DownloadFile(context.Background(), cid, filePath, WithResume(), WithCIDVerification(nil), WithProgress(func(n, total int64) {
+			if first < 0 {
+				first = n
+			}
+			last = n
+			assert.Equal(t, int64(len(content)), total)
+		}))
+		assert.NoError(t, err)
+		assert.Greater(t, first, int64(1000))
+		assert.Equal(t, int64(len(content)), last)
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, content, data)
+
+		// 已下载完成
+		assert.NoError(t, api.DownloadFile(context.Background(), cid, filePath, WithResume(), WithCIDVerification(nil)))
+	})
+
+	t.Run("range not supported", func(t *testing.T) {
+		server := newFileTestServer(t, content, false)
+		defer server.Close()
+		api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, GinServerUrl: server.URL})
+
+		_, err := api.DownloadFileToWriter(context.Background(), cid, io.Discard, WithOffset(100))
+		assert.ErrorIs(t, err, ErrRangeNotSupported)
+
+		// 写入文件时从头开始下载
+		filePath := filepath.Join(t.TempDir(), "data.bin")
+		assert.NoError(t, os.WriteFile(filePath, content[:1000], 0o644))
+		assert.NoError(t, api.DownloadFile(context.Background(), cid, filePath, WithResume(), WithCIDVerification(nil)))
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+}
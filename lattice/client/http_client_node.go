@@ -8,7 +8,7 @@ import (
 )
 
 func (api *httpApi) GetNodeInfo(ctx context.Context) (*types.NodeInfo, error) {
-	response, err := Post[types.NodeInfo](ctx, api.Url, NewJsonRpcBody("node_nodeInfo"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[types.NodeInfo](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_nodeInfo"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ func (api *httpApi) GetNodeInfo(ctx context.Context) (*types.NodeInfo, error) {
 }
 
 func (api *httpApi) GetConsensusNodesStatus(ctx context.Context, chainID string) ([]*types.ConsensusNodeStatus, error) {
-	response, err := Post[[]*types.ConsensusNodeStatus](ctx, api.Url, NewJsonRpcBody("witness_nodeList"), api.newHeaders(chainID), api.transport)
+	response, err := Post[[]*types.ConsensusNodeStatus](api.withLogger(ctx), api.Url, NewJsonRpcBody("witness_nodeList"), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +30,7 @@ func (api *httpApi) GetConsensusNodesStatus(ctx context.Context, chainID string)
 }
 
 func (api *httpApi) GetGenesisNodeAddress(ctx context.Context, chainID string) (string, error) {
-	response, err := Post[string](ctx, api.Url, NewJsonRpcBody("wallet_getGenesisNode"), api.newHeaders(chainID), api.transport)
+	response, err := Post[string](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_getGenesisNode"), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return "", err
 	}
@@ -41,7 +41,7 @@ func (api *httpApi) GetGenesisNodeAddress(ctx context.Context, chainID string) (
 }
 
 func (api *httpApi) GetNodePeers(ctx context.Context, chainID string) ([]*types.NodePeer, error) {
-	response, err := Post[[]*types.NodePeer](ctx, api.Url, NewJsonRpcBody("node_peers"), api.newHeaders(chainID), api.transport)
+	response, err := Post[[]*types.NodePeer](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_peers"), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +52,7 @@ func (api *httpApi) GetNodePeers(ctx context.Context, chainID string) ([]*types.
 }
 
 func (api *httpApi) GetNodeConfig(ctx context.Context, chainID string) (*types.NodeConfig, error) {
-	response, err := Post[types.NodeConfig](ctx, api.Url, NewJsonRpcBody("latc_getConfig"), api.newHeaders(chainID), api.transport)
+	response, err := Post[types.NodeConfig](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getConfig"), api.newHeaders(chainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +63,7 @@ func (api *httpApi) GetNodeConfig(ctx context.Context, chainID string) (*types.N
 }
 
 func (api *httpApi) GetNodeProtocol(ctx context.Context, chainId string) (*types.NodeProtocol, error) {
-	response, err := Post[types.NodeProtocol](ctx, api.Url, NewJsonRpcBody("latc_getProtocols"), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.NodeProtocol](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getProtocols"), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +74,7 @@ func (api *httpApi) GetNodeProtocol(ctx context.Context, chainId string) (*types
 }
 
 func (api *httpApi) GetNodeConfirmedConfiguration(ctx context.Context, chainId string) (*types.NodeConfirmedConfiguration, error) {
-	response, err := Post[types.NodeConfirmedConfiguration](ctx, api.Url, NewJsonRpcBody("wallet_getConfirmConfig"), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.NodeConfirmedConfiguration](api.withLogger(ctx), api.Url, NewJsonRpcBody("wallet_getConfirmConfig"), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +85,7 @@ func (api *httpApi) GetNodeConfirmedConfiguration(ctx context.Context, chainId s
 }
 
 func (api *httpApi) GetNodeVersion(ctx context.Context) (*types.NodeVersion, error) {
-	response, err := Post[types.NodeVersion](ctx, api.Url, NewJsonRpcBody("node_nodeVersion"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[types.NodeVersion](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_nodeVersion"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +96,7 @@ func (api *httpApi) GetNodeVersion(ctx context.Context) (*types.NodeVersion, err
 }
 
 func (api *httpApi) GetNodeSaintKey(ctx context.Context) (*wallet.FileKey, error) {
-	response, err := Post[wallet.FileKey](ctx, api.Url, NewJsonRpcBody("node_getSaintKey"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[wallet.FileKey](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_getSaintKey"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +107,7 @@ func (api *httpApi) GetNodeSaintKey(ctx context.Context) (*wallet.FileKey, error
 }
 
 func (api *httpApi) GetNodeConfiguration(ctx context.Context) (*types.NodeConfiguration, error) {
-	response, err := Post[types.NodeConfiguration](ctx, api.Url, NewJsonRpcBody("latc_getConfig"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[types.NodeConfiguration](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_getConfig"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +118,7 @@ func (api *httpApi) GetNodeConfiguration(ctx context.Context) (*types.NodeConfig
 }
 
 func (api *httpApi) GetNodeWorkingDirectory(ctx context.Context) (string, error) {
-	response, err := Post[string](ctx, api.Url, NewJsonRpcBody("node_getLocationPath"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[string](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_getLocationPath"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return "", err
 	}
@@ -129,7 +129,7 @@ func (api *httpApi) GetNodeWorkingDirectory(ctx context.Context) (string, error)
 }
 
 func (api *httpApi) GetSnapshot(ctx context.Context, chainId string, daemonBlockHeight *big.Int) (*types.NodeProtocolConfig, error) {
-	response, err := Post[types.NodeProtocolConfig](ctx, api.Url, NewJsonRpcBody("clique_getSnapshot", daemonBlockHeight), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.NodeProtocolConfig](api.withLogger(ctx), api.Url, NewJsonRpcBody("clique_getSnapshot", daemonBlockHeight), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +140,7 @@ func (api *httpApi) GetSnapshot(ctx context.Context, chainId string, daemonBlock
 }
 
 func (api *httpApi) GetLatcInfo(ctx context.Context, chainId string) (*types.NodeProtocolConfig, error) {
-	response, err := Post[types.NodeProtocolConfig](ctx, api.Url, NewJsonRpcBody("latc_latcInfo"), api.newHeaders(chainId), api.transport)
+	response, err := Post[types.NodeProtocolConfig](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_latcInfo"), api.newHeaders(chainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -6,7 +6,7 @@ import (
 )
 
 func (api *httpApi) GetSubchain(ctx context.Context, subchainId string) (*types.Subchain, error) {
-	response, err := Post[types.Subchain](ctx, api.Url, NewJsonRpcBody("latc_latcInfo"), api.newHeaders(subchainId), api.transport)
+	response, err := Post[types.Subchain](api.withLogger(ctx), api.Url, NewJsonRpcBody("latc_latcInfo"), api.newHeaders(subchainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +17,7 @@ func (api *httpApi) GetSubchain(ctx context.Context, subchainId string) (*types.
 }
 
 func (api *httpApi) GetCreatedSubchain(ctx context.Context) ([]uint64, error) {
-	response, err := Post[[]uint64](ctx, api.Url, NewJsonRpcBody("cbyc_getCreatedAllChains"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[[]uint64](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_getCreatedAllChains"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +28,7 @@ func (api *httpApi) GetCreatedSubchain(ctx context.Context) ([]uint64, error) {
 }
 
 func (api *httpApi) GetJoinedSubchain(ctx context.Context) ([]uint64, error) {
-	response, err := Post[[]uint64](ctx, api.Url, NewJsonRpcBody("node_getAllChainId"), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[[]uint64](api.withLogger(ctx), api.Url, NewJsonRpcBody("node_getAllChainId"), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +39,7 @@ func (api *httpApi) GetJoinedSubchain(ctx context.Context) ([]uint64, error) {
 }
 
 func (api *httpApi) GetSubchainRunningStatus(ctx context.Context, subchainID string) (*types.SubchainRunningStatus, error) {
-	response, err := Post[string](ctx, api.Url, NewJsonRpcBody("cbyc_getChainStatus"), api.newHeaders(subchainID), api.transport)
+	response, err := Post[string](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_getChainStatus"), api.newHeaders(subchainID), api.transport)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +54,7 @@ func (api *httpApi) GetSubchainRunningStatus(ctx context.Context, subchainID str
 }
 
 func (api *httpApi) JoinSubchain(ctx context.Context, subchainId, networkId uint64, inode string) error {
-	response, err := Post[any](ctx, api.Url, NewJsonRpcBody("cbyc_selfJoinChain", subchainId, networkId, inode), api.newHeaders(emptyChainId), api.transport)
+	response, err := Post[any](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_selfJoinChain", subchainId, networkId, inode), api.newHeaders(emptyChainId), api.transport)
 	if err != nil {
 		return err
 	}
@@ -65,7 +65,7 @@ func (api *httpApi) JoinSubchain(ctx context.Context, subchainId, networkId uint
 }
 
 func (api *httpApi) StartSubchain(ctx context.Context, subchainId string) error {
-	response, err := Post[any](ctx, api.Url, NewJsonRpcBody("cbyc_startSelfChain"), api.newHeaders(subchainId), api.transport)
+	response, err := Post[any](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_startSelfChain"), api.newHeaders(subchainId), api.transport)
 	if err != nil {
 		return err
 	}
@@ -76,7 +76,7 @@ func (api *httpApi) StartSubchain(ctx context.Context, subchainId string) error
 }
 
 func (api *httpApi) StopSubchain(ctx context.Context, subchainId string) error {
-	response, err := Post[any](ctx, api.Url, NewJsonRpcBody("cbyc_stopSelfChain"), api.newHeaders(subchainId), api.transport)
+	response, err := Post[any](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_stopSelfChain"), api.newHeaders(subchainId), api.transport)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func (api *httpApi) StopSubchain(ctx context.Context, subchainId string) error {
 }
 
 func (api *httpApi) DeleteSubchain(ctx context.Context, subchainId string) error {
-	response, err := Post[any](ctx, api.Url, NewJsonRpcBody("cbyc_delSelfChain"), api.newHeaders(subchainId), api.transport)
+	response, err := Post[any](api.withLogger(ctx), api.Url, NewJsonRpcBody("cbyc_delSelfChain"), api.newHeaders(subchainId), api.transport)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// 模拟节点的批量请求处理，倒序返回响应，哈希为 0x00 的回执不存在
+func newBatchTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var bodies []*JsonRpcBody
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&bodies))
+		responses := make([]map[string]interface{}, 0, len(bodies))
+		for i := len(bodies) - 1; i >= 0; i-- {
+			body := bodies[i]
+			response := map[string]interface{}{"id": body.Id, "jsonrpc": "2.0"}
+			if body.Params[0] == "0x00" {
+				response["error"] = JsonRpcError{Code: -32000, Message: "receipt not found"}
+			} else {
+				response["result"] = types.Receipt{ContractRet: body.Params[0].(string), DBlockNumber: 1}
+			}
+			responses = append(responses, response)
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+}
+
+func TestBatchPost(t *testing.T) {
+	server := newBatchTestServer(t)
+	defer server.Close()
+
+	bodies := []*JsonRpcBody{NewJsonRpcBody("latc_getReceipt", "0x01"), NewJsonRpcBody("latc_getReceipt", "0x00"), NewJsonRpcBody("latc_getReceipt", "0x02")}
+	responses, err := BatchPost[types.Receipt](context.Background(), server.URL, bodies, nil, http.DefaultTransport)
+	assert.NoError(t, err)
+	assert.Len(t, responses, 3)
+	assert.Equal(t, "0x01", responses[0].Result.ContractRet)
+	assert.NotNil(t, responses[1].Error)
+	assert.Equal(t, "0x02", responses[2].Result.ContractRet)
+}
+
+func TestHttpApi_GetReceipts(t *testing.T) {
+	server := newBatchTestServer(t)
+	defer server.Close()
+	api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL})
+
+	hashes := make([]string, maxBatchSize+2)
+	for i := range hashes {
+		hashes[i] = "0x01"
+	}
+	hashes[maxBatchSize] = "0x00"
+	receipts, err := api.GetReceipts(context.Background(), "1", hashes)
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, receipts, len(hashes))
+	for i, receipt := range receipts {
+		if i == maxBatchSize {
+			assert.Nil(t, receipt)
+			assert.Error(t, batchErr.Errors[i])
+		} else {
+			assert.Equal(t, "0x01", receipt.ContractRet)
+			assert.NoError(t, batchErr.Errors[i])
+		}
+	}
+}
+
+func TestHttpApi_GetReceipts_ChunkFailed(t *testing.T) {
+	var requests atomic.Int32
+	batchServer := newBatchTestServer(t)
+	defer batchServer.Close()
+	// 第二个批次的http请求失败
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		batchServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL})
+
+	hashes := make([]string, 2*maxBatchSize+1)
+	for i := range hashes {
+		hashes[i] = "0x01"
+	}
+	receipts, err := api.GetReceipts(context.Background(), "1", hashes)
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, receipts, len(hashes))
+	assert.Equal(t, int32(2), requests.Load())
+	for i, receipt := range receipts {
+		if i < maxBatchSize {
+			assert.Equal(t, "0x01", receipt.ContractRet)
+			assert.NoError(t, batchErr.Errors[i])
+		} else {
+			assert.Nil(t, receipt)
+			assert.Error(t, batchErr.Errors[i])
+		}
+	}
+}
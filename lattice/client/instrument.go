@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// 无法识别Json-Rpc方法的请求，如gin服务的上传接口
+	unknownRpcMethod = "unknown"
+	// 错误码，请求成功时为空
+	errorCodeTransport = "transport"
+	errorCodeTimeout   = "timeout"
+)
+
+type rpcMethodKey struct{}
+
+// 在请求的context中记录Json-Rpc的方法名，供 instrumentedTransport 使用
+func withRpcMethod(ctx context.Context, jsonRpcBody interface{}) context.Context {
+	var method string
+	switch body := jsonRpcBody.(type) {
+	case *JsonRpcBody:
+		method = body.Method
+	case []*JsonRpcBody:
+		if len(body) > 0 {
+			method = "batch/" + body[0].Method
+		}
+	}
+	if method == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, rpcMethodKey{}, method)
+}
+
+func rpcMethodFromContext(ctx context.Context) string {
+	if method, ok := ctx.Value(rpcMethodKey{}).(string); ok {
+		return method
+	}
+	return unknownRpcMethod
+}
+
+// MetricsRecorder 记录http请求和区块缓存的指标，默认实现为 NewMetricsRegistry，也可以对接Prometheus等监控系统
+type MetricsRecorder interface {
+	// ObserveRequest 记录一次请求
+	//
+	// Parameters:
+	//   - method string: Json-Rpc的方法名，批量请求为 batch/方法名
+	//   - duration time.Duration: 耗时
+	//   - errorCode string: 成功时为空，Json-Rpc错误为错误码，http错误为 http_状态码，网络错误为 transport 或 timeout
+	ObserveRequest(method string, duration time.Duration, errorCode string)
+
+	// AddInFlight 调整进行中的请求数
+	AddInFlight(method string, delta int64)
+
+	// ObserveBlockCache 记录一次区块缓存的查询
+	//
+	// Parameters:
+	//   - hit bool: 是否命中缓存，未命中或需要刷新守护区块哈希时为false
+	ObserveBlockCache(hit bool)
+}
+
+// Span 链路追踪的span
+type Span interface {
+	// SetAttribute 设置属性
+	SetAttribute(key string, value interface{})
+
+	// RecordError 记录错误
+	RecordError(err error)
+
+	// Headers 需要注入到http请求中传播的头，如W3C的traceparent
+	Headers() map[string]string
+
+	// End 结束span
+	End()
+}
+
+// Tracer 链路追踪，可以适配OpenTelemetry等实现，span通过context传递
+type Tracer interface {
+	// Start 开始一个span，返回的context包含该span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// NewInstrumentedTransport 为http请求增加指标和链路追踪，可以通过 HttpApiInitParam.Transport 或 lattice.WithTransport 使用
+//
+// Parameters:
+//   - next http.RoundTripper: 为nil时使用 http.DefaultTransport
+//   - recorder MetricsRecorder: 为nil时不记录指标
+//   - tracer Tracer: 为nil时不追踪
+//
+// Returns:
+//   - http.RoundTripper
+func NewInstrumentedTransport(next http.RoundTripper, recorder MetricsRecorder, tracer Tracer) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{next: next, recorder: recorder, tracer: tracer}
+}
+
+type instrumentedTransport struct {
+	next     http.RoundTripper
+	recorder MetricsRecorder
+	tracer   Tracer
+}
+
+func (t *instrumentedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	method := rpcMethodFromContext(request.Context())
+	var span Span
+	if t.tracer != nil {
+		var ctx context.Context
+		ctx, span = t.tracer.Start(request.Context(), "jsonrpc "+method)
+		span.SetAttribute("rpc.method", method)
+		span.SetAttribute("http.url", request.URL.String())
+		request = request.Clone(ctx)
+		for key, value := range span.Headers() {
+			request.Header.Set(key, value)
+		}
+		defer span.End()
+	}
+	if t.recorder != nil {
+		t.recorder.AddInFlight(method, 1)
+		defer t.recorder.AddInFlight(method, -1)
+	}
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	var errorCode string
+	switch {
+	case err != nil:
+		errorCode = lo.Ternary(errors.Is(wrapTransportError(err), errs.ErrTimeout), errorCodeTimeout, errorCodeTransport)
+		if span != nil {
+			span.RecordError(err)
+		}
+	case response.StatusCode != http.StatusOK:
+		errorCode = fmt.Sprintf("http_%d", response.StatusCode)
+		if span != nil {
+			span.RecordError(fmt.Errorf("http status %d", response.StatusCode))
+		}
+	case method != unknownRpcMethod:
+		errorCode = peekRpcErrorCode(response)
+		if span != nil && errorCode != "" {
+			span.RecordError(fmt.Errorf("json-rpc error %s", errorCode))
+		}
+	}
+	if t.recorder != nil {
+		t.recorder.ObserveRequest(method, time.Since(start), errorCode)
+	}
+	if span != nil && response != nil {
+		span.SetAttribute("http.status_code", response.StatusCode)
+	}
+	return response, err
+}
+
+// 读取响应中Json-Rpc的错误码，并还原响应体，批量请求返回第一个错误的错误码
+func peekRpcErrorCode(response *http.Response) string {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return errorCodeTransport
+	}
+
+	type rpcError struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	var responses []rpcError
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		_ = json.Unmarshal(trimmed, &responses)
+	} else {
+		single := rpcError{}
+		_ = json.Unmarshal(trimmed, &single)
+		responses = append(responses, single)
+	}
+	for _, r := range responses {
+		if r.Error != nil {
+			return strconv.Itoa(r.Error.Code)
+		}
+	}
+	return ""
+}
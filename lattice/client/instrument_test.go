@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordingSpan) RecordError(err error)                      { s.err = err }
+func (s *recordingSpan) Headers() map[string]string {
+	return map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+}
+func (s *recordingSpan) End() { s.ended = true }
+
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		body := new(JsonRpcBody)
+		_ = json.NewDecoder(r.Body).Decode(body)
+		if body.Method == "latc_getReceipt" {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"receipt not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	registry := NewMetricsRegistry()
+	tracer := new(recordingTracer)
+	transport := NewInstrumentedTransport(http.DefaultTransport, registry, tracer)
+
+	response, err := Post[string](context.Background(), server.URL, NewJsonRpcBody("latc_getCurrentTDBlock"), nil, transport)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", *response.Result)
+	_, err = Post[string](context.Background(), server.URL, NewJsonRpcBody("latc_getReceipt"), nil, transport)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1), registry.RequestCount("latc_getCurrentTDBlock"))
+	assert.Equal(t, uint64(1), registry.ErrorCount("latc_getReceipt", "-32000"))
+	assert.Equal(t, int64(0), registry.InFlight())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceparent)
+	assert.Len(t, tracer.spans, 2)
+	assert.Equal(t, "jsonrpc latc_getReceipt", tracer.spans[1].name)
+	assert.Error(t, tracer.spans[1].err)
+	assert.True(t, tracer.spans[1].ended)
+
+	registry.ObserveBlockCache(true)
+	registry.ObserveBlockCache(false)
+	assert.Equal(t, 0.5, registry.BlockCacheHitRate())
+
+	var b strings.Builder
+	assert.NoError(t, registry.WritePrometheus(&b))
+	assert.Contains(t, b.String(), `lattice_rpc_requests_total{method="latc_getReceipt"} 1`)
+	assert.Contains(t, b.String(), `lattice_rpc_errors_total{method="latc_getReceipt",code="-32000"} 1`)
+	assert.Contains(t, b.String(), `lattice_rpc_request_duration_seconds_count{method="latc_getCurrentTDBlock"} 1`)
+	assert.Contains(t, b.String(), `lattice_block_cache_requests_total{result="hit"} 1`)
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 请求耗时直方图的桶(s)
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricsRegistry 进程内的指标注册表，实现了 MetricsRecorder，可以按Prometheus的文本格式导出
+type MetricsRegistry interface {
+	MetricsRecorder
+
+	// RequestCount 获取方法的请求次数
+	RequestCount(method string) uint64
+
+	// ErrorCount 获取方法某个错误码的错误次数
+	ErrorCount(method, errorCode string) uint64
+
+	// InFlight 获取进行中的请求数
+	InFlight() int64
+
+	// BlockCacheHitRate 获取区块缓存的命中率，没有查询时为0
+	BlockCacheHitRate() float64
+
+	// WritePrometheus 按Prometheus的文本格式输出所有指标，可以挂载到 /metrics 接口
+	//
+	// Parameters:
+	//   - w io.Writer
+	//
+	// Returns:
+	//   - error
+	WritePrometheus(w io.Writer) error
+}
+
+// NewMetricsRegistry 初始化进程内的指标注册表
+//
+// Returns:
+//   - MetricsRegistry
+func NewMetricsRegistry() MetricsRegistry {
+	return &metricsRegistry{
+		requests: make(map[string]*latencyHistogram),
+		errors:   make(map[errorKey]uint64),
+		inFlight: make(map[string]int64),
+	}
+}
+
+type errorKey struct {
+	method    string
+	errorCode string
+}
+
+type latencyHistogram struct {
+	buckets []uint64 // 与 defaultLatencyBuckets 对应，非累计
+	count   uint64
+	sum     float64
+}
+
+type metricsRegistry struct {
+	mu          sync.Mutex
+	requests    map[string]*latencyHistogram
+	errors      map[errorKey]uint64
+	inFlight    map[string]int64
+	cacheHits   uint64
+	cacheMisses uint64
+}
+
+func (r *metricsRegistry) ObserveRequest(method string, duration time.Duration, errorCode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	histogram, ok := r.requests[method]
+	if !ok {
+		histogram = &latencyHistogram{buckets: make([]uint64, len(defaultLatencyBuckets))}
+		r.requests[method] = histogram
+	}
+	seconds := duration.Seconds()
+	histogram.count++
+	histogram.sum += seconds
+	for i, bound := range defaultLatencyBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+			break
+		}
+	}
+	if errorCode != "" {
+		r.errors[errorKey{method: method, errorCode: errorCode}]++
+	}
+}
+
+func (r *metricsRegistry) AddInFlight(method string, delta int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight[method] += delta
+}
+
+func (r *metricsRegistry) ObserveBlockCache(hit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hit {
+		r.cacheHits++
+	} else {
+		r.cacheMisses++
+	}
+}
+
+func (r *metricsRegistry) RequestCount(method string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if histogram, ok := r.requests[method]; ok {
+		return histogram.count
+	}
+	return 0
+}
+
+func (r *metricsRegistry) ErrorCount(method, errorCode string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errors[errorKey{method: method, errorCode: errorCode}]
+}
+
+func (r *metricsRegistry) InFlight() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, n := range r.inFlight {
+		total += n
+	}
+	return total
+}
+
+func (r *metricsRegistry) BlockCacheHitRate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if total := r.cacheHits + r.cacheMisses; total > 0 {
+		return float64(r.cacheHits) / float64(total)
+	}
+	return 0
+}
+
+func (r *metricsRegistry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	methods := make([]string, 0, len(r.requests))
+	for method := range r.requests {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	b.WriteString("# HELP lattice_rpc_requests_total Total number of JSON-RPC requests.\n")
+	b.WriteString("# TYPE lattice_rpc_requests_total counter\n")
+	for _, method := range methods {
+		fmt.Fprintf(&b, "lattice_rpc_requests_total{method=%q} %d\n", method, r.requests[method].count)
+	}
+
+	b.WriteString("# HELP lattice_rpc_errors_total Total number of failed JSON-RPC requests by error code.\n")
+	b.WriteString("# TYPE lattice_rpc_errors_total counter\n")
+	errorKeys := make([]errorKey, 0, len(r.errors))
+	for key := range r.errors {
+		errorKeys = append(errorKeys, key)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		if errorKeys[i].method != errorKeys[j].method {
+			return errorKeys[i].method < errorKeys[j].method
+		}
+		return errorKeys[i].errorCode < errorKeys[j].errorCode
+	})
+	for _, key := range errorKeys {
+		fmt.Fprintf(&b, "lattice_rpc_errors_total{method=%q,code=%q} %d\n", key.method, key.errorCode, r.errors[key])
+	}
+
+	b.WriteString("# HELP lattice_rpc_request_duration_seconds JSON-RPC request latency.\n")
+	b.WriteString("# TYPE lattice_rpc_request_duration_seconds histogram\n")
+	for _, method := range methods {
+		histogram := r.requests[method]
+		var cumulative uint64
+		for i, bound := range defaultLatencyBuckets {
+			cumulative += histogram.buckets[i]
+			fmt.Fprintf(&b, "lattice_rpc_request_duration_seconds_bucket{method=%q,le=\"%g\"} %d\n", method, bound, cumulative)
+		}
+		fmt.Fprintf(&b, "lattice_rpc_request_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, histogram.count)
+		fmt.Fprintf(&b, "lattice_rpc_request_duration_seconds_sum{method=%q} %g\n", method, histogram.sum)
+		fmt.Fprintf(&b, "lattice_rpc_request_duration_seconds_count{method=%q} %d\n", method, histogram.count)
+	}
+
+	b.WriteString("# HELP lattice_rpc_in_flight_requests Number of JSON-RPC requests in flight.\n")
+	b.WriteString("# TYPE lattice_rpc_in_flight_requests gauge\n")
+	inFlightMethods := make([]string, 0, len(r.inFlight))
+	for method := range r.inFlight {
+		inFlightMethods = append(inFlightMethods, method)
+	}
+	sort.Strings(inFlightMethods)
+	for _, method := range inFlightMethods {
+		fmt.Fprintf(&b, "lattice_rpc_in_flight_requests{method=%q} %d\n", method, r.inFlight[method])
+	}
+
+	b.WriteString("# HELP lattice_block_cache_requests_total Block cache lookups by result.\n")
+	b.WriteString("# TYPE lattice_block_cache_requests_total counter\n")
+	fmt.Fprintf(&b, "lattice_block_cache_requests_total{result=\"hit\"} %d\n", r.cacheHits)
+	fmt.Fprintf(&b, "lattice_block_cache_requests_total{result=\"miss\"} %d\n", r.cacheMisses)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,333 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RoutingPolicy 多节点时http请求的路由策略
+//
+//   - RoutingPolicyRoundRobin       轮询
+//   - RoutingPolicyLeastLatency     选择延迟最低的节点
+//   - RoutingPolicyStickyPerAccount 同一个账户的请求固定路由到同一个节点，节点不可用时才会迁移，没有账户信息的请求按轮询路由
+type RoutingPolicy string
+
+const (
+	RoutingPolicyRoundRobin       RoutingPolicy = "RoundRobin"
+	RoutingPolicyLeastLatency     RoutingPolicy = "LeastLatency"
+	RoutingPolicyStickyPerAccount RoutingPolicy = "StickyPerAccount"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+	// 延迟的指数加权移动平均的权重
+	latencyEwmaWeight = 0.2
+)
+
+type routingKey struct{}
+
+// 发送交易等非幂等的请求，网络错误时节点可能已经收到了请求，不能切换到其它节点重发
+type noFailoverKey struct{}
+
+func withoutFailover(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noFailoverKey{}, true)
+}
+
+// WithAccountRouting 为请求附加账户信息，在 RoutingPolicyStickyPerAccount 策略下同一个账户的请求会路由到同一个节点
+//
+// Parameters:
+//   - ctx context.Context
+//   - chainId string
+//   - accountAddress string
+//
+// Returns:
+//   - context.Context
+func WithAccountRouting(ctx context.Context, chainId, accountAddress string) context.Context {
+	return context.WithValue(ctx, routingKey{}, fmt.Sprintf("%s_%s", chainId, accountAddress))
+}
+
+func routingKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(routingKey{}).(string)
+	return key
+}
+
+// NodeEndpoint 节点的请求地址
+type NodeEndpoint struct {
+	HttpUrl      string // 节点的URL
+	GinServerUrl string // 节点gin服务路径
+}
+
+// NodeStatus 节点的健康状态
+//   - HttpUrl       节点的URL
+//   - Healthy       最近一次健康检查或请求是否成功
+//   - Latency       请求延迟的加权平均值
+//   - LastError     最近一次失败的原因
+//   - LastCheckedAt 最近一次健康检查的时间
+type NodeStatus struct {
+	HttpUrl       string
+	Healthy       bool
+	Latency       time.Duration
+	LastError     error
+	LastCheckedAt time.Time
+}
+
+// 节点，url为解析后的http地址和gin服务地址
+type poolNode struct {
+	endpoint  *NodeEndpoint
+	httpUrl   *url.URL
+	ginUrl    *url.URL
+	healthApi HttpApi // 不经过节点池的http客户端，用于健康检查
+
+	mu            sync.RWMutex
+	healthy       bool
+	latency       time.Duration
+	lastError     error
+	lastCheckedAt time.Time
+}
+
+func (n *poolNode) isHealthy() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.healthy
+}
+
+func (n *poolNode) getLatency() time.Duration {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.latency
+}
+
+func (n *poolNode) markSuccess(latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.healthy = true
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		n.latency = time.Duration(latencyEwmaWeight*float64(latency) + (1-latencyEwmaWeight)*float64(n.latency))
+	}
+}
+
+func (n *poolNode) markFailure(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.healthy = false
+	n.lastError = err
+}
+
+func (n *poolNode) status() *NodeStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return &NodeStatus{
+		HttpUrl:       n.endpoint.HttpUrl,
+		Healthy:       n.healthy,
+		Latency:       n.latency,
+		LastError:     n.lastError,
+		LastCheckedAt: n.lastCheckedAt,
+	}
+}
+
+// nodePool 在多个节点间路由http请求的 http.RoundTripper，网络错误时切换到其它节点重试，发送交易的请求除外
+//
+// 请求总是按第一个节点的地址构造，发送前再改写为选中节点的地址
+type nodePool struct {
+	nodes     []*poolNode
+	policy    RoutingPolicy
+	transport http.RoundTripper
+	counter   atomic.Uint64
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	logger    *logger.Helper
+}
+
+func newNodePool(endpoints []*NodeEndpoint, policy RoutingPolicy, healthCheckInterval time.Duration, transport http.RoundTripper, log *logger.Helper) (*nodePool, error) {
+	if policy == "" {
+		policy = RoutingPolicyStickyPerAccount
+	}
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = defaultHealthCheckInterval
+	}
+	pool := &nodePool{
+		policy:    policy,
+		transport: transport,
+		closeCh:   make(chan struct{}),
+		logger:    log,
+	}
+	for _, endpoint := range endpoints {
+		httpUrl, err := url.Parse(endpoint.HttpUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node url %s: %w", endpoint.HttpUrl, err)
+		}
+		ginUrl, err := url.Parse(endpoint.GinServerUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node gin server url %s: %w", endpoint.GinServerUrl, err)
+		}
+		pool.nodes = append(pool.nodes, &poolNode{
+			endpoint: endpoint,
+			httpUrl:  httpUrl,
+			ginUrl:   ginUrl,
+			healthApi: &httpApi{
+				Url:          endpoint.HttpUrl,
+				GinServerUrl: endpoint.GinServerUrl,
+				transport:    transport,
+				logger:       log,
+			},
+			healthy: true,
+		})
+	}
+	go pool.healthCheckLoop(healthCheckInterval)
+	return pool, nil
+}
+
+func (p *nodePool) RoundTrip(req *http.Request) (*http.Response, error) {
+	primary := p.nodes[0]
+	isGinRequest := req.URL.Host == primary.ginUrl.Host && req.URL.Host != primary.httpUrl.Host
+	key := routingKeyFrom(req.Context())
+
+	tried := make([]bool, len(p.nodes))
+	var lastErr error
+	for attempt := 0; attempt < len(p.nodes); attempt++ {
+		index := p.pick(key, tried)
+		tried[index] = true
+		node := p.nodes[index]
+
+		r := req.Clone(req.Context())
+		target := node.httpUrl
+		if isGinRequest {
+			target = node.ginUrl
+		}
+		r.URL.Scheme, r.URL.Host, r.Host = target.Scheme, target.Host, ""
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		start := time.Now()
+		resp, err := p.transport.RoundTrip(r)
+		if err == nil {
+			node.markSuccess(time.Since(start))
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		node.markFailure(err)
+		if req.Context().Value(noFailoverKey{}) != nil {
+			p.logger.Warn("Request to the node failed, not retrying the non-idempotent request", "节点请求失败，非幂等的请求不切换节点重试", logger.String("node", node.endpoint.HttpUrl), logger.Err(err))
+			return nil, err
+		}
+		p.logger.Warn("Request to the node failed, retrying with another node", "节点请求失败，切换节点重试", logger.String("node", node.endpoint.HttpUrl), logger.Err(err))
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// 选择节点，优先选择健康且未尝试过的节点，都不健康时选择未尝试过的节点
+func (p *nodePool) pick(key string, tried []bool) int {
+	candidates := make([]int, 0, len(p.nodes))
+	for i, node := range p.nodes {
+		if !tried[i] && node.isHealthy() {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range p.nodes {
+			if !tried[i] {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	switch {
+	case p.policy == RoutingPolicyStickyPerAccount && key != "":
+		// rendezvous hashing, only the accounts of an unavailable node are moved
+		best, bestScore := candidates[0], uint64(0)
+		for _, i := range candidates {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(key))
+			_, _ = h.Write([]byte(p.nodes[i].endpoint.HttpUrl))
+			if score := h.Sum64(); score >= bestScore {
+				best, bestScore = i, score
+			}
+		}
+		return best
+	case p.policy == RoutingPolicyLeastLatency:
+		best := candidates[0]
+		for _, i := range candidates[1:] {
+			// 延迟未知的节点优先，以便测量其延迟
+			if latency := p.nodes[i].getLatency(); latency < p.nodes[best].getLatency() {
+				best = i
+			}
+		}
+		return best
+	default:
+		return candidates[p.counter.Add(1)%uint64(len(candidates))]
+	}
+}
+
+func (p *nodePool) healthCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.healthCheck()
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *nodePool) healthCheck() {
+	var wg sync.WaitGroup
+	for _, node := range p.nodes {
+		wg.Add(1)
+		go func(node *poolNode) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			_, err := node.healthApi.GetNodeVersion(ctx)
+			if err != nil {
+				if node.isHealthy() {
+					p.logger.Warn("Health check of the node failed", "节点健康检查失败", logger.String("node", node.endpoint.HttpUrl), logger.Err(err))
+				}
+				node.markFailure(err)
+			} else {
+				node.markSuccess(time.Since(start))
+			}
+			node.mu.Lock()
+			node.lastCheckedAt = time.Now()
+			node.mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+}
+
+func (p *nodePool) statuses() []*NodeStatus {
+	statuses := make([]*NodeStatus, len(p.nodes))
+	for i, node := range p.nodes {
+		statuses[i] = node.status()
+	}
+	return statuses
+}
+
+func (p *nodePool) close() {
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+	})
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 模拟节点，账户的最新高度为节点的编号，便于判断请求路由到了哪个节点
+func newPoolTestServer(t *testing.T, index uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body JsonRpcBody
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		response := map[string]interface{}{"id": body.Id, "jsonrpc": "2.0"}
+		switch body.Method {
+		case "node_nodeVersion":
+			response["result"] = types.NodeVersion{Version: "test"}
+		case "wallet_sendRawTBlock":
+			response["result"] = common.BigToHash(new(big.Int).SetUint64(index))
+		default:
+			response["result"] = types.LatestBlock{Height: index}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+}
+
+func newPoolTestApi(policy RoutingPolicy, servers ...*httptest.Server) HttpApi {
+	nodes := make([]*NodeEndpoint, 0, len(servers)-1)
+	for _, server := range servers[1:] {
+		nodes = append(nodes, &NodeEndpoint{HttpUrl: server.URL, GinServerUrl: server.URL})
+	}
+	return NewHttpApi(&HttpApiInitParam{
+		HttpUrl:             servers[0].URL,
+		GinServerUrl:        servers[0].URL,
+		Nodes:               nodes,
+		RoutingPolicy:       policy,
+		HealthCheckInterval: time.Hour,
+	})
+}
+
+func TestNodePool_RoundRobin(t *testing.T) {
+	servers := []*httptest.Server{newPoolTestServer(t, 0), newPoolTestServer(t, 1), newPoolTestServer(t, 2)}
+	for _, server := range servers {
+		defer server.Close()
+	}
+	api := newPoolTestApi(RoutingPolicyRoundRobin, servers...)
+	defer api.Close()
+
+	visited := make(map[uint64]bool)
+	for i := 0; i < len(servers); i++ {
+		block, err := api.GetLatestBlock(context.Background(), "1", "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi")
+		assert.NoError(t, err)
+		visited[block.Height] = true
+	}
+	assert.Len(t, visited, len(servers))
+}
+
+func TestNodePool_StickyPerAccount(t *testing.T) {
+	servers := []*httptest.Server{newPoolTestServer(t, 0), newPoolTestServer(t, 1), newPoolTestServer(t, 2)}
+	for _, server := range servers {
+		defer server.Close()
+	}
+	api := newPoolTestApi(RoutingPolicyStickyPerAccount, servers...)
+	defer api.Close()
+
+	address := "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi"
+	ctx := WithAccountRouting(context.Background(), "1", address)
+	first, err := api.GetLatestBlock(ctx, "1", address)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		block, err := api.GetLatestBlock(ctx, "1", address)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Height, block.Height)
+	}
+
+	// the sticky node goes down, requests fail over to another node and stay there
+	servers[first.Height].Close()
+	failover, err := api.GetLatestBlock(ctx, "1", address)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Height, failover.Height)
+	block, err := api.GetLatestBlock(ctx, "1", address)
+	assert.NoError(t, err)
+	assert.Equal(t, failover.Height, block.Height)
+
+	statuses := api.NodeStatuses()
+	assert.Len(t, statuses, len(servers))
+	assert.False(t, statuses[first.Height].Healthy)
+	assert.Error(t, statuses[first.Height].LastError)
+}
+
+func TestNodePool_SendWithoutFailover(t *testing.T) {
+	servers := []*httptest.Server{newPoolTestServer(t, 0), newPoolTestServer(t, 1)}
+	defer servers[1].Close()
+	api := newPoolTestApi(RoutingPolicyRoundRobin, servers...)
+	defer api.Close()
+
+	// 等待首次健康检查完成，再模拟发送交易时节点不可用，不切换到其它节点重发
+	assert.Eventually(t, func() bool {
+		statuses := api.NodeStatuses()
+		return !statuses[0].LastCheckedAt.IsZero() && !statuses[1].LastCheckedAt.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+	servers[0].Close()
+	failed := 0
+	for i := 0; i < 2; i++ {
+		if _, err := api.SendSignedTransaction(context.Background(), "1", &block.Transaction{}); err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+	assert.False(t, api.NodeStatuses()[0].Healthy)
+
+	_, err := NewHttpApiWithError(&HttpApiInitParam{HttpUrl: servers[1].URL, Nodes: []*NodeEndpoint{{HttpUrl: "http://[::1"}}})
+	assert.Error(t, err)
+}
+
+func TestNodePool_HealthCheck(t *testing.T) {
+	servers := []*httptest.Server{newPoolTestServer(t, 0), newPoolTestServer(t, 1)}
+	defer servers[0].Close()
+	servers[1].Close()
+
+	api := newPoolTestApi(RoutingPolicyLeastLatency, servers...)
+	defer api.Close()
+
+	assert.Eventually(t, func() bool {
+		statuses := api.NodeStatuses()
+		return statuses[0].Healthy && !statuses[1].Healthy && !statuses[1].LastCheckedAt.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		block, err := api.GetLatestBlock(context.Background(), "1", "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), block.Height)
+	}
+}
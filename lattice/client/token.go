@@ -0,0 +1,441 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// JwtAlgorithmHS256 HMAC-SHA256，使用 JwtConfig.Secret 签名
+	JwtAlgorithmHS256 = "HS256"
+	// JwtAlgorithmRS256 RSA-SHA256，使用 *rsa.PrivateKey 签名
+	JwtAlgorithmRS256 = "RS256"
+	// JwtAlgorithmES256 ECDSA P-256 SHA256，使用 *ecdsa.PrivateKey 签名
+	JwtAlgorithmES256 = "ES256"
+	// JwtAlgorithmSM2 国密SM2-SM3，使用 *sm2.PrivateKey 签名，签名为r||s共64字节
+	JwtAlgorithmSM2 = "SM2"
+
+	defaultJwtIssuer             = "lattice_go"
+	defaultJwtSubject            = "jwt"
+	defaultJwtExpirationDuration = time.Hour
+	// token在过期前刷新的最大提前量
+	maxTokenRefreshAhead = 3 * time.Minute
+	// 获取token的超时时间
+	tokenFetchTimeout = 30 * time.Second
+)
+
+// SigningMethodSM2 jwt的SM2签名算法，已通过 jwt.RegisterSigningMethod 注册，解析token时可以直接使用
+var SigningMethodSM2 jwt.SigningMethod = &signingMethodSM2{}
+
+func init() {
+	jwt.RegisterSigningMethod(JwtAlgorithmSM2, func() jwt.SigningMethod { return SigningMethodSM2 })
+}
+
+type signingMethodSM2 struct{}
+
+func (m *signingMethodSM2) Alg() string {
+	return JwtAlgorithmSM2
+}
+
+func (m *signingMethodSM2) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(*sm2.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	r, s, err := sm2.Sm2Sign(privateKey, []byte(signingString), nil, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return jwt.EncodeSegment(signature), nil
+}
+
+func (m *signingMethodSM2) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(*sm2.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if len(sig) != 64 {
+		return jwt.ErrSignatureInvalid
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !sm2.Sm2Verify(publicKey, []byte(signingString), nil, r, s) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// TokenProvider 提供请求节点时使用的token，会以 Bearer 的形式设置在请求头 Authorization 中，实现需要是并发安全的
+type TokenProvider interface {
+	// Token 获取token
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//
+	// Returns:
+	//   - string
+	//   - error
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc 函数形式的 TokenProvider
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// NewStaticTokenProvider 使用外部签发的固定token
+//
+// Parameters:
+//   - token string
+//
+// Returns:
+//   - TokenProvider
+func NewStaticTokenProvider(token string) TokenProvider {
+	return TokenProviderFunc(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// TokenFetcher 签发或从外部获取token
+//
+// Returns:
+//   - string: token
+//   - time.Time: 过期时间，为零值时表示不过期
+//   - error
+type TokenFetcher func(ctx context.Context) (string, time.Time, error)
+
+// NewRefreshingTokenProvider 缓存token并在过期前刷新，刷新期间继续使用未过期的token，
+// 并发请求只会触发一次刷新，没有可用token的请求等待刷新的结果
+//
+// Parameters:
+//   - fetch TokenFetcher
+//   - refreshAhead time.Duration: 在过期前多久刷新
+//
+// Returns:
+//   - TokenProvider
+func NewRefreshingTokenProvider(fetch TokenFetcher, refreshAhead time.Duration) TokenProvider {
+	return &refreshingTokenProvider{fetch: fetch, refreshAhead: refreshAhead}
+}
+
+type refreshingTokenProvider struct {
+	fetch        TokenFetcher
+	refreshAhead time.Duration
+
+	mu    sync.Mutex
+	cache JwtTokenCache
+	call  *tokenCall // 进行中的刷新
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+func (p *refreshingTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	now := time.Now()
+	cache := p.cache
+	unexpired := cache.Token != "" && (cache.ExpireAt.IsZero() || now.Before(cache.ExpireAt))
+	if unexpired && (cache.ExpireAt.IsZero() || now.Add(p.refreshAhead).Before(cache.ExpireAt)) {
+		p.mu.Unlock()
+		return cache.Token, nil
+	}
+	call := p.call
+	if call == nil {
+		call = p.refresh()
+	}
+	p.mu.Unlock()
+
+	// 未过期的token在后台刷新期间继续使用
+	if unexpired {
+		return cache.Token, nil
+	}
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// 在后台刷新token，使用独立的ctx，调用方取消时不会中断其它请求共享的刷新，调用时需要持有 p.mu
+func (p *refreshingTokenProvider) refresh() *tokenCall {
+	call := &tokenCall{done: make(chan struct{})}
+	p.call = call
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+		defer cancel()
+		token, expireAt, err := p.fetch(ctx)
+		p.mu.Lock()
+		call.token, call.err = token, err
+		if err == nil {
+			p.cache = JwtTokenCache{Token: token, ExpireAt: expireAt}
+		} else {
+			// 失败时保留缓存的token，过期前的请求仍然可用
+			logger.Warn("Failed to refresh the token", "刷新token失败", logger.Err(err))
+		}
+		p.call = nil
+		p.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// JwtConfig 本地签发jwt token的配置
+//   - Algorithm          签名算法，JwtAlgorithmHS256(默认)、JwtAlgorithmRS256、JwtAlgorithmES256、JwtAlgorithmSM2
+//   - Secret             HS256的secret
+//   - PrivateKey         非对称算法的私钥，RS256为*rsa.PrivateKey，ES256为P-256的*ecdsa.PrivateKey，SM2为*sm2.PrivateKey
+//   - ExpirationDuration token的有效期，默认1h
+//   - Issuer             签发者，默认lattice_go
+//   - Subject            主题，默认jwt
+//   - Audience           接收者，默认为空
+//   - Claims             自定义的claims，不能覆盖 exp、iat、nbf、jti
+type JwtConfig struct {
+	Algorithm          string
+	Secret             string
+	PrivateKey         crypto.Signer
+	ExpirationDuration time.Duration
+	Issuer             string
+	Subject            string
+	Audience           []string
+	Claims             map[string]interface{}
+}
+
+// NewJwtWithConfig 根据配置初始化jwt，签发的token会被缓存并在过期前刷新
+//
+// Parameters:
+//   - config *JwtConfig
+//
+// Returns:
+//   - Jwt
+//   - error: 算法不支持或密钥与算法不匹配
+func NewJwtWithConfig(config *JwtConfig) (Jwt, error) {
+	if config == nil {
+		return nil, errors.New("jwt config is nil")
+	}
+	j := &jwtImpl{
+		issuer:             config.Issuer,
+		subject:            config.Subject,
+		audience:           config.Audience,
+		claims:             config.Claims,
+		ExpirationDuration: config.ExpirationDuration,
+	}
+	if j.issuer == "" {
+		j.issuer = defaultJwtIssuer
+	}
+	if j.subject == "" {
+		j.subject = defaultJwtSubject
+	}
+	if j.ExpirationDuration <= 0 {
+		j.ExpirationDuration = defaultJwtExpirationDuration
+	}
+
+	var keyOk bool
+	switch config.Algorithm {
+	case "", JwtAlgorithmHS256:
+		j.Algorithm = jwt.SigningMethodHS256
+		j.signingKey, j.verifyingKey = []byte(config.Secret), []byte(config.Secret)
+		keyOk = config.Secret != ""
+	case JwtAlgorithmRS256:
+		j.Algorithm = jwt.SigningMethodRS256
+		_, keyOk = config.PrivateKey.(*rsa.PrivateKey)
+	case JwtAlgorithmES256:
+		j.Algorithm = jwt.SigningMethodES256
+		var privateKey *ecdsa.PrivateKey
+		privateKey, keyOk = config.PrivateKey.(*ecdsa.PrivateKey)
+		keyOk = keyOk && privateKey.Curve.Params().BitSize == 256
+	case JwtAlgorithmSM2:
+		j.Algorithm = SigningMethodSM2
+		_, keyOk = config.PrivateKey.(*sm2.PrivateKey)
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %s", config.Algorithm)
+	}
+	if !keyOk {
+		return nil, fmt.Errorf("invalid key for jwt algorithm %s", j.Algorithm.Alg())
+	}
+	if j.signingKey == nil {
+		j.signingKey, j.verifyingKey = config.PrivateKey, config.PrivateKey.Public()
+	}
+
+	j.provider = NewRefreshingTokenProvider(func(context.Context) (string, time.Time, error) {
+		return j.generateToken()
+	}, min(maxTokenRefreshAhead, j.ExpirationDuration/2))
+	return j, nil
+}
+
+// 为请求设置 Authorization 请求头
+type authTransport struct {
+	next     http.RoundTripper
+	provider TokenProvider
+}
+
+// 未配置 TokenProvider 时返回原transport
+func newAuthTransport(next http.RoundTripper, provider TokenProvider) http.RoundTripper {
+	if provider == nil {
+		return next
+	}
+	return &authTransport{next: next, provider: provider}
+}
+
+func (t *authTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	token, err := t.provider.Token(request.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the token: %w", err)
+	}
+	request = request.Clone(request.Context())
+	request.Header.Set(headerAuthorize, fmt.Sprintf("Bearer %s", token))
+	return t.next.RoundTrip(request)
+}
+
+// 优先使用 TokenProvider，否则根据jwt的secret签发
+func newTokenProvider(provider TokenProvider, secret string, expirationDuration time.Duration) TokenProvider {
+	if provider != nil {
+		return provider
+	}
+	if secret == "" {
+		return nil
+	}
+	return NewJwt(secret, expirationDuration)
+}
+
+// NewJwt 使用HS256签发token
+//
+// Parameters:
+//   - secret string: 为空时返回nil
+//   - expirationDuration time.Duration: token的有效期，默认1h
+//
+// Returns:
+//   - Jwt
+func NewJwt(secret string, expirationDuration time.Duration) Jwt {
+	if secret == "" {
+		return nil
+	}
+	j, _ := NewJwtWithConfig(&JwtConfig{Secret: secret, ExpirationDuration: expirationDuration})
+	return j
+}
+
+// JwtTokenCache jwt token的缓存
+type JwtTokenCache struct {
+	Token    string
+	ExpireAt time.Time
+}
+
+// IsValid 验证Token是否有效
+//
+// Returns:
+//   - error
+func (cache *JwtTokenCache) IsValid() error {
+	if cache.Token == "" {
+		return errors.New("token is empty")
+	}
+
+	if time.Now().After(cache.ExpireAt) {
+		return errors.New("token is expired")
+	}
+
+	return nil
+}
+
+type Jwt interface {
+	TokenProvider
+	// GenerateToken 签发一个新的token，不会更新缓存
+	GenerateToken() (string, error)
+	// ParseToken 使用配置的密钥验证并解析token
+	ParseToken(token string) (*jwt.Token, error)
+	// GetToken 获取缓存的token，即将过期时重新签发
+	GetToken() (string, error)
+}
+
+type jwtImpl struct {
+	Algorithm          jwt.SigningMethod // 签名算法
+	ExpirationDuration time.Duration     // token过期时长
+	signingKey         interface{}       // 签名的密钥
+	verifyingKey       interface{}       // 验签的密钥
+	issuer             string
+	subject            string
+	audience           []string
+	claims             map[string]interface{}
+	provider           TokenProvider // 缓存签发的token
+}
+
+func (j *jwtImpl) generateToken() (string, time.Time, error) {
+	now := time.Now()
+	expireAt := now.Add(j.ExpirationDuration)
+	claims := jwt.MapClaims{
+		"iss": j.issuer,
+		"sub": j.subject,
+	}
+	if len(j.audience) > 0 {
+		claims["aud"] = j.audience
+	}
+	for key, value := range j.claims {
+		claims[key] = value
+	}
+	claims["exp"] = jwt.NewNumericDate(expireAt)
+	claims["iat"] = jwt.NewNumericDate(now)
+	claims["nbf"] = jwt.NewNumericDate(now)
+	claims["jti"] = uuid.NewString()
+
+	token, err := jwt.NewWithClaims(j.Algorithm, claims).SignedString(j.signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expireAt, nil
+}
+
+func (j *jwtImpl) GenerateToken() (string, error) {
+	token, _, err := j.generateToken()
+	return token, err
+}
+
+func (j *jwtImpl) ParseToken(token string) (*jwt.Token, error) {
+	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != j.Algorithm.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+		}
+		return j.verifyingKey, nil
+	})
+
+	switch {
+	case err == nil && t.Valid:
+		return t, nil
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return nil, errors.New("that's not even a token")
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return nil, errors.New("invalid signature")
+	case errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet):
+		return nil, errors.New("token is either expired or not active yet")
+	default:
+		return nil, fmt.Errorf("couldn't handle this token: %w", err)
+	}
+}
+
+func (j *jwtImpl) Token(ctx context.Context) (string, error) {
+	return j.provider.Token(ctx)
+}
+
+func (j *jwtImpl) GetToken() (string, error) {
+	return j.provider.Token(context.Background())
+}
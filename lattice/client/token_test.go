@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/tjfoc/gmsm/sm2"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewJwtWithConfig(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	sm2Key, err := sm2.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	configs := []*JwtConfig{
+		{Secret: "secret"},
+		{Algorithm: JwtAlgorithmRS256, PrivateKey: rsaKey},
+		{Algorithm: JwtAlgorithmES256, PrivateKey: ecdsaKey},
+		{Algorithm: JwtAlgorithmSM2, PrivateKey: sm2Key},
+	}
+	for _, config := range configs {
+		config.Audience = []string{"node"}
+		config.Claims = map[string]interface{}{"tenant": "lattice", "exp": 1}
+		j, err := NewJwtWithConfig(config)
+		assert.NoError(t, err)
+
+		token, err := j.GetToken()
+		assert.NoError(t, err)
+		parsed, err := j.ParseToken(token)
+		assert.NoError(t, err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, "lattice", claims["tenant"])
+		assert.Equal(t, "lattice_go", claims["iss"])
+		assert.True(t, claims.VerifyAudience("node", true))
+		assert.True(t, claims.VerifyExpiresAt(time.Now().Add(50*time.Minute).Unix(), true))
+
+		cached, err := j.Token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, token, cached)
+	}
+
+	// 其它密钥签发的token验签失败
+	other, err := NewJwtWithConfig(&JwtConfig{Algorithm: JwtAlgorithmSM2, PrivateKey: sm2Key})
+	assert.NoError(t, err)
+	token, err := other.GenerateToken()
+	assert.NoError(t, err)
+	anotherKey, _ := sm2.GenerateKey(rand.Reader)
+	another, _ := NewJwtWithConfig(&JwtConfig{Algorithm: JwtAlgorithmSM2, PrivateKey: anotherKey})
+	_, err = another.ParseToken(token)
+	assert.Error(t, err)
+	_, err = NewJwt("secret", 0).ParseToken(token)
+	assert.Error(t, err)
+
+	_, err = NewJwtWithConfig(&JwtConfig{Algorithm: JwtAlgorithmES256, PrivateKey: rsaKey})
+	assert.Error(t, err)
+	_, err = NewJwtWithConfig(&JwtConfig{Algorithm: "PS256", PrivateKey: rsaKey})
+	assert.Error(t, err)
+	_, err = NewJwtWithConfig(&JwtConfig{})
+	assert.Error(t, err)
+}
+
+func TestRefreshingTokenProvider(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	provider := NewRefreshingTokenProvider(func(ctx context.Context) (string, time.Time, error) {
+		n := fetches.Add(1)
+		<-release
+		if n == 1 {
+			return "", time.Time{}, errors.New("issuer unavailable")
+		}
+		return "token", time.Now().Add(time.Hour), nil
+	}, time.Minute)
+
+	// 并发请求只触发一次刷新，共享刷新的结果
+	var wg sync.WaitGroup
+	results := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = provider.Token(context.Background())
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+	for _, err := range results {
+		assert.EqualError(t, err, "issuer unavailable")
+	}
+
+	// 失败不缓存，下次请求重新获取
+	token, err := provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	token, err = provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestRefreshingTokenProvider_RefreshAhead(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	provider := NewRefreshingTokenProvider(func(ctx context.Context) (string, time.Time, error) {
+		if fetches.Add(1) == 1 {
+			// 首次获取的token在刷新窗口内
+			return "old", time.Now().Add(time.Minute), nil
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return "", time.Time{}, ctx.Err()
+		}
+		return "new", time.Now().Add(time.Hour), nil
+	}, 2*time.Minute)
+
+	token, err := provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "old", token)
+
+	// 刷新期间继续使用未过期的token，且只触发一次刷新
+	for i := 0; i < 3; i++ {
+		token, err = provider.Token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "old", token)
+	}
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 10*time.Millisecond)
+	token, err = provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "old", token)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		token, err := provider.Token(context.Background())
+		return err == nil && token == "new"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestRefreshingTokenProvider_DetachedFetch(t *testing.T) {
+	release := make(chan struct{})
+	provider := NewRefreshingTokenProvider(func(ctx context.Context) (string, time.Time, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return "", time.Time{}, ctx.Err()
+		}
+		return "token", time.Now().Add(time.Hour), nil
+	}, time.Minute)
+
+	// 调用方取消只影响自身，不会中断共享的刷新
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := provider.Token(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	token, err := provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
+
+func TestHttpApi_TokenProvider(t *testing.T) {
+	var authorization atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get(headerAuthorize))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": map[string]interface{}{}})
+	}))
+	defer server.Close()
+
+	api := NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, JwtSecret: "secret", TokenProvider: NewStaticTokenProvider("external")})
+	_, err := api.GetNodeVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer external", authorization.Load())
+
+	api = NewHttpApi(&HttpApiInitParam{HttpUrl: server.URL, TokenProvider: TokenProviderFunc(func(context.Context) (string, error) {
+		return "", errors.New("no token")
+	})})
+	_, err = api.GetNodeVersion(context.Background())
+	assert.ErrorContains(t, err, "no token")
+}
@@ -1 +1,319 @@
 package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	subscriptionNamespace            = "latc"
+	subscriptionNewDaemonBlock       = "newDBlock"
+	subscriptionNewTransactionBlock  = "newTBlock"
+	subscriptionEvents               = "logs"
+	defaultReconnectInterval         = time.Second
+	defaultMaxReconnectInterval      = 30 * time.Second
+	defaultWebsocketDialTimeout      = 15 * time.Second
+	defaultWebsocketSubscribeTimeout = 15 * time.Second
+)
+
+// ErrWebsocketClosed websocket客户端已关闭
+var ErrWebsocketClosed = errors.New("websocket client is closed")
+
+// WebsocketApiInitParam 初始化Websocket API的参数
+type WebsocketApiInitParam struct {
+	WebsocketUrl               string        // 节点的Websocket URL，示例：ws://192.168.1.185:13801
+	JwtSecret                  string        // jwt的secret信息
+	JwtTokenExpirationDuration time.Duration // jwt token的过期时间
+	TokenProvider              TokenProvider // 不为空时代替JwtSecret，用于自定义签名算法、claims或外部签发的token
+	ReconnectInterval          time.Duration // 断线重连的初始间隔，默认1s，每次失败后翻倍
+	MaxReconnectInterval       time.Duration // 断线重连的最大间隔，默认30s
+	Logger                     logger.Logger // 该实例的日志，为nil时使用 logger.SetLogger 设置的进程级日志
+}
+
+func NewWebsocketApi(args *WebsocketApiInitParam) WebsocketApi {
+	reconnectInterval := args.ReconnectInterval
+	if reconnectInterval <= 0 {
+		reconnectInterval = defaultReconnectInterval
+	}
+	maxReconnectInterval := args.MaxReconnectInterval
+	if maxReconnectInterval < reconnectInterval {
+		maxReconnectInterval = defaultMaxReconnectInterval
+	}
+	return &websocketApi{
+		Url:                  args.WebsocketUrl,
+		tokenProvider:        newTokenProvider(args.TokenProvider, args.JwtSecret, args.JwtTokenExpirationDuration),
+		reconnectInterval:    reconnectInterval,
+		maxReconnectInterval: maxReconnectInterval,
+		clients:              make(map[string]*rpc.Client),
+		closeCh:              make(chan struct{}),
+		logger:               logger.NewHelper(args.Logger),
+	}
+}
+
+// WebsocketApi 节点的Websocket客户端，基于JSON-RPC订阅推送链上的数据，连接断开后会自动重连并重新订阅
+type WebsocketApi interface {
+	// SubscribeNewDaemonBlock 订阅新产生的守护区块
+	//
+	// Parameters:
+	//   - ctx context.Context: 仅作用于首次订阅
+	//   - chainId string
+	//   - ch chan<- *types.DaemonBlock: 接收守护区块的通道
+	//
+	// Returns:
+	//   - Subscription
+	//   - error
+	SubscribeNewDaemonBlock(ctx context.Context, chainId string, ch chan<- *types.DaemonBlock) (Subscription, error)
+
+	// SubscribeNewTransactionBlock 订阅账户新产生的交易区块
+	//
+	// Parameters:
+	//   - ctx context.Context: 仅作用于首次订阅
+	//   - chainId string
+	//   - accountAddress string: 账户地址，zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi
+	//   - ch chan<- *types.TransactionBlock: 接收交易区块的通道
+	//
+	// Returns:
+	//   - Subscription
+	//   - error
+	SubscribeNewTransactionBlock(ctx context.Context, chainId, accountAddress string, ch chan<- *types.TransactionBlock) (Subscription, error)
+
+	// SubscribeEvents 订阅合约事件
+	//
+	// Parameters:
+	//   - ctx context.Context: 仅作用于首次订阅
+	//   - chainId string
+	//   - filter *types.EventFilter: 事件的过滤条件，为nil时订阅全部事件
+	//   - ch chan<- *types.Event: 接收合约事件的通道
+	//
+	// Returns:
+	//   - Subscription
+	//   - error
+	SubscribeEvents(ctx context.Context, chainId string, filter *types.EventFilter, ch chan<- *types.Event) (Subscription, error)
+
+	// Close 关闭所有的连接和订阅
+	Close()
+}
+
+// Subscription 订阅
+type Subscription interface {
+	// Unsubscribe 取消订阅，并关闭 Err 返回的通道
+	Unsubscribe()
+
+	// Err 订阅无法恢复时(例如客户端已关闭)会收到错误，网络中断等可恢复的错误会自动重连，不会出现在该通道
+	Err() <-chan error
+}
+
+type websocketApi struct {
+	Url                  string        // 节点的Websocket请求路径
+	tokenProvider        TokenProvider // 建立连接时设置 Authorization 请求头
+	reconnectInterval    time.Duration // 断线重连的初始间隔
+	maxReconnectInterval time.Duration // 断线重连的最大间隔
+	logger               *logger.Helper
+
+	mu      sync.Mutex
+	clients map[string]*rpc.Client // 每条链维护一个连接，key为chainId
+	closed  bool
+	closeCh chan struct{}
+}
+
+func (api *websocketApi) SubscribeNewDaemonBlock(ctx context.Context, chainId string, ch chan<- *types.DaemonBlock) (Subscription, error) {
+	return api.subscribe(ctx, chainId, ch, subscriptionNewDaemonBlock)
+}
+
+func (api *websocketApi) SubscribeNewTransactionBlock(ctx context.Context, chainId, accountAddress string, ch chan<- *types.TransactionBlock) (Subscription, error) {
+	return api.subscribe(ctx, chainId, ch, subscriptionNewTransactionBlock, accountAddress)
+}
+
+func (api *websocketApi) SubscribeEvents(ctx context.Context, chainId string, filter *types.EventFilter, ch chan<- *types.Event) (Subscription, error) {
+	if filter == nil {
+		filter = new(types.EventFilter)
+	}
+	return api.subscribe(ctx, chainId, ch, subscriptionEvents, filter)
+}
+
+func (api *websocketApi) Close() {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.closed {
+		return
+	}
+	api.closed = true
+	close(api.closeCh)
+	for chainId, c := range api.clients {
+		c.Close()
+		delete(api.clients, chainId)
+	}
+}
+
+// 获取链的连接，不存在时建立新的连接，建立连接时不持有锁，避免慢连接阻塞其它链和其它订阅
+func (api *websocketApi) client(ctx context.Context, chainId string) (*rpc.Client, error) {
+	api.mu.Lock()
+	if api.closed {
+		api.mu.Unlock()
+		return nil, ErrWebsocketClosed
+	}
+	if c, ok := api.clients[chainId]; ok {
+		api.mu.Unlock()
+		return c, nil
+	}
+	api.mu.Unlock()
+
+	c, err := rpc.DialOptions(ctx, api.Url, rpc.WithHTTPAuth(func(h http.Header) error {
+		h.Set(headerChainID, chainId)
+		if api.tokenProvider != nil {
+			token, err := api.tokenProvider.Token(ctx)
+			if err != nil {
+				return err
+			}
+			h.Set(headerAuthorize, fmt.Sprintf("Bearer %s", token))
+		}
+		return nil
+	}))
+	if err != nil {
+		api.logger.Error("Failed to establish the websocket connection", "建立Websocket连接失败", logger.String("url", api.Url), logger.String("chainId", chainId), logger.Err(err))
+		return nil, err
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.closed {
+		c.Close()
+		return nil, ErrWebsocketClosed
+	}
+	// 并发建立了连接时使用先建立的连接
+	if current, ok := api.clients[chainId]; ok {
+		c.Close()
+		return current, nil
+	}
+	api.clients[chainId] = c
+	return c, nil
+}
+
+// 丢弃已断开的连接，仅当该连接仍是链的当前连接时才关闭，避免多个订阅重复重连
+func (api *websocketApi) dropClient(chainId string, c *rpc.Client) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if current, ok := api.clients[chainId]; ok && current == c {
+		delete(api.clients, chainId)
+		c.Close()
+	}
+}
+
+func (api *websocketApi) subscribe(ctx context.Context, chainId string, ch interface{}, args ...interface{}) (Subscription, error) {
+	c, err := api.client(ctx, chainId)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := c.Subscribe(ctx, subscriptionNamespace, ch, args...)
+	if err != nil {
+		api.dropClient(chainId, c)
+		api.logger.Error("Failed to subscribe", "订阅失败", logger.String("chainId", chainId), logger.Any("args", args), logger.Err(err))
+		return nil, err
+	}
+
+	sub := &reconnectingSubscription{
+		api:     api,
+		chainId: chainId,
+		channel: ch,
+		args:    args,
+		unsubCh: make(chan struct{}),
+		errCh:   make(chan error, 1),
+	}
+	go sub.loop(c, inner)
+	return sub, nil
+}
+
+// reconnectingSubscription 断线后自动重连并重新订阅的订阅
+type reconnectingSubscription struct {
+	api     *websocketApi
+	chainId string
+	channel interface{}   // 用户的接收通道，重新订阅时复用
+	args    []interface{} // 订阅参数，重新订阅时复用
+
+	once    sync.Once
+	unsubCh chan struct{}
+	errCh   chan error
+}
+
+func (s *reconnectingSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.unsubCh)
+	})
+}
+
+func (s *reconnectingSubscription) Err() <-chan error {
+	return s.errCh
+}
+
+func (s *reconnectingSubscription) loop(c *rpc.Client, inner *rpc.ClientSubscription) {
+	defer close(s.errCh)
+	for {
+		select {
+		case <-s.unsubCh:
+			inner.Unsubscribe()
+			return
+		case <-s.api.closeCh:
+			inner.Unsubscribe()
+			s.errCh <- ErrWebsocketClosed
+			return
+		case err := <-inner.Err():
+			s.api.logger.Warn("Subscription disconnected, resubscribing", "订阅已断开，开始重新订阅", logger.String("chainId", s.chainId), logger.Any("args", s.args), logger.Err(err))
+			s.api.dropClient(s.chainId, c)
+			if c, inner = s.resubscribe(); inner == nil {
+				return
+			}
+		}
+	}
+}
+
+// 按指数退避重新建立连接并订阅，取消订阅或客户端关闭时返回nil
+func (s *reconnectingSubscription) resubscribe() (*rpc.Client, *rpc.ClientSubscription) {
+	interval := s.api.reconnectInterval
+	for {
+		select {
+		case <-s.unsubCh:
+			return nil, nil
+		case <-s.api.closeCh:
+			s.errCh <- ErrWebsocketClosed
+			return nil, nil
+		case <-time.After(interval):
+		}
+
+		c, inner, err := s.trySubscribe()
+		if err == nil {
+			s.api.logger.Info("Resubscribed successfully", "重新订阅成功", logger.String("chainId", s.chainId), logger.Any("args", s.args))
+			return c, inner
+		}
+		if errors.Is(err, ErrWebsocketClosed) {
+			s.errCh <- err
+			return nil, nil
+		}
+		s.api.logger.Warn("Failed to resubscribe, retrying later", "重新订阅失败，稍后重试", logger.String("chainId", s.chainId), logger.Duration("interval", interval), logger.Err(err))
+		interval = min(interval*2, s.api.maxReconnectInterval)
+	}
+}
+
+func (s *reconnectingSubscription) trySubscribe() (*rpc.Client, *rpc.ClientSubscription, error) {
+	dialCtx, cancel := context.WithTimeout(context.Background(), defaultWebsocketDialTimeout)
+	defer cancel()
+	c, err := s.api.client(dialCtx, s.chainId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subscribeCtx, cancel := context.WithTimeout(context.Background(), defaultWebsocketSubscribeTimeout)
+	defer cancel()
+	inner, err := c.Subscribe(subscribeCtx, subscriptionNamespace, s.channel, s.args...)
+	if err != nil {
+		s.api.dropClient(s.chainId, c)
+		return nil, nil, err
+	}
+	return c, inner, nil
+}
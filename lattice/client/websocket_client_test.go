@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLatcService struct {
+	height atomic.Int64
+}
+
+func (s *testLatcService) NewDBlock(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sub.Err():
+				return
+			case <-ticker.C:
+				block := &types.DaemonBlock{Height: big.NewInt(s.height.Add(1))}
+				if err := notifier.Notify(sub.ID, block); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func newTestRpcServer(t *testing.T) *rpc.Server {
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName(subscriptionNamespace, new(testLatcService)))
+	return server
+}
+
+func TestWebsocketApi_SubscribeNewDaemonBlock(t *testing.T) {
+	var current atomic.Pointer[rpc.Server]
+	current.Store(newTestRpcServer(t))
+	var chainIdHeader atomic.Value
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chainIdHeader.Store(r.Header.Get(headerChainID))
+		current.Load().WebsocketHandler([]string{"*"}).ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	api := NewWebsocketApi(&WebsocketApiInitParam{
+		WebsocketUrl:      "ws" + strings.TrimPrefix(httpServer.URL, "http"),
+		ReconnectInterval: 10 * time.Millisecond,
+	})
+	defer api.Close()
+
+	ch := make(chan *types.DaemonBlock, 16)
+	sub, err := api.SubscribeNewDaemonBlock(context.Background(), "1", ch)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", chainIdHeader.Load())
+
+	receive := func() *types.DaemonBlock {
+		select {
+		case block := <-ch:
+			return block
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for daemon block")
+			return nil
+		}
+	}
+	assert.NotNil(t, receive().Height)
+
+	// drop all connections, the subscription should reconnect and resubscribe transparently
+	old := current.Swap(newTestRpcServer(t))
+	old.Stop()
+	for len(ch) > 0 {
+		<-ch
+	}
+	assert.NotNil(t, receive().Height)
+
+	sub.Unsubscribe()
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+}
+
+func TestWebsocketApi_Close(t *testing.T) {
+	server := newTestRpcServer(t)
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer httpServer.Close()
+
+	api := NewWebsocketApi(&WebsocketApiInitParam{WebsocketUrl: "ws" + strings.TrimPrefix(httpServer.URL, "http")})
+	sub, err := api.SubscribeNewDaemonBlock(context.Background(), "1", make(chan *types.DaemonBlock, 16))
+	assert.NoError(t, err)
+
+	api.Close()
+	assert.ErrorIs(t, <-sub.Err(), ErrWebsocketClosed)
+
+	_, err = api.SubscribeNewDaemonBlock(context.Background(), "1", make(chan *types.DaemonBlock))
+	assert.ErrorIs(t, err, ErrWebsocketClosed)
+}
+
+func TestWebsocketApi_SlowDial(t *testing.T) {
+	server := newTestRpcServer(t)
+	release := make(chan struct{})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerChainID) == "2" {
+			<-release
+		}
+		server.WebsocketHandler([]string{"*"}).ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+	defer close(release)
+
+	api := NewWebsocketApi(&WebsocketApiInitParam{WebsocketUrl: "ws" + strings.TrimPrefix(httpServer.URL, "http")})
+	defer api.Close()
+
+	// 链2的连接迟迟无法建立，不影响链1
+	go func() {
+		_, _ = api.SubscribeNewDaemonBlock(context.Background(), "2", make(chan *types.DaemonBlock, 16))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := api.SubscribeNewDaemonBlock(ctx, "1", make(chan *types.DaemonBlock, 16))
+	assert.NoError(t, err)
+
+	// 订阅失败时丢弃连接
+	_, err = api.SubscribeNewTransactionBlock(ctx, "1", "zltc_Z1pnS94bP4hQSYLs4aP4UwBP9pH8bEvhi", make(chan *types.TransactionBlock))
+	assert.Error(t, err)
+	impl := api.(*websocketApi)
+	impl.mu.Lock()
+	_, ok := impl.clients["1"]
+	impl.mu.Unlock()
+	assert.False(t, ok)
+}
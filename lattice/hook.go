@@ -0,0 +1,136 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"math/big"
+)
+
+var (
+	// ErrAmountExceedsLimit 转账额度超过限额
+	ErrAmountExceedsLimit = errors.New("the amount exceeds the limit")
+	// ErrLinkerNotAllowed 交易的Linker不在白名单中
+	ErrLinkerNotAllowed = errors.New("the linker is not in the whitelist")
+)
+
+// TxContext 交易在生命周期各阶段的上下文
+//   - ChainId     链ID
+//   - Owner       交易的发起者
+//   - Request     交易请求，BeforeBuild 阶段可以修改
+//   - Transaction 交易，BeforeSign 阶段开始有值，BeforeSign 阶段可以修改
+//   - Hash        交易哈希，AfterSend 阶段开始有值
+//   - Receipt     交易回执，OnReceipt 阶段有值
+//   - Err         发生的错误，OnError 阶段有值
+type TxContext struct {
+	ChainId     string
+	Owner       string
+	Request     *TxRequest
+	Transaction *block.Transaction
+	Hash        *common.Hash
+	Receipt     *types.Receipt
+	Err         error
+}
+
+// TxHooks 交易生命周期的钩子，未设置的阶段会被跳过，多个钩子按注册的顺序执行，可用于审计日志、策略检查、监控等
+//   - BeforeBuild 构造交易前，返回错误时中止交易
+//   - BeforeSign  签名交易前，返回错误时中止交易
+//   - BeforeSend  发送交易前，每次发送(包括高度冲突后的重发)都会执行，返回错误时中止交易
+//   - AfterSend   交易发送成功后，交易已上链，返回的错误只记录日志
+//   - OnReceipt   等待到回执后，返回的错误只记录日志
+//   - OnError     交易在任一阶段失败时执行
+//
+// TransactionPipeline 和 BroadcastEnvelope 发送的交易已由调用方构造，只执行 BeforeSend、AfterSend、OnError，
+// 此时交易已签名，BeforeSend 不能修改交易
+type TxHooks struct {
+	BeforeBuild func(ctx context.Context, tx *TxContext) error
+	BeforeSign  func(ctx context.Context, tx *TxContext) error
+	BeforeSend  func(ctx context.Context, tx *TxContext) error
+	AfterSend   func(ctx context.Context, tx *TxContext) error
+	OnReceipt   func(ctx context.Context, tx *TxContext) error
+	OnError     func(ctx context.Context, tx *TxContext)
+}
+
+// NewAmountLimitHook 限制单笔交易的转账额度，在发送前检查最终签名的交易，其它钩子对交易的修改同样受限
+//
+// Parameters:
+//   - maxAmount uint64: 最大转账额度
+//
+// Returns:
+//   - *TxHooks
+func NewAmountLimitHook(maxAmount uint64) *TxHooks {
+	limit := new(big.Int).SetUint64(maxAmount)
+	return &TxHooks{
+		BeforeSend: func(_ context.Context, tx *TxContext) error {
+			if amount := tx.Transaction.Amount; amount != nil && amount.Cmp(limit) > 0 {
+				return fmt.Errorf("%w: %s > %d", ErrAmountExceedsLimit, amount, maxAmount)
+			}
+			return nil
+		},
+	}
+}
+
+// NewLinkerWhitelistHook 只允许向白名单中的地址转账或调用白名单中的合约，部署合约不受限制，
+// 在发送前检查最终签名的交易，其它类型的交易Linker为空或零地址时不允许发送
+//
+// Parameters:
+//   - linkers ...string: 允许的账户地址或合约地址
+//
+// Returns:
+//   - *TxHooks
+func NewLinkerWhitelistHook(linkers ...string) *TxHooks {
+	whitelist := make(map[string]struct{}, len(linkers))
+	for _, linker := range linkers {
+		whitelist[linker] = struct{}{}
+	}
+	return &TxHooks{
+		BeforeSend: func(_ context.Context, tx *TxContext) error {
+			transaction := tx.Transaction
+			if isDeployTransactionType(transaction.Type) {
+				return nil
+			}
+			if transaction.Linker == "" || transaction.Linker == constant.ZeroAddress {
+				return fmt.Errorf("%w: empty linker", ErrLinkerNotAllowed)
+			}
+			if _, ok := whitelist[transaction.Linker]; !ok {
+				return fmt.Errorf("%w: %s", ErrLinkerNotAllowed, transaction.Linker)
+			}
+			return nil
+		},
+	}
+}
+
+// 按顺序执行各个钩子的某个阶段，返回第一个错误
+func (svc *lattice) runTxHooks(ctx context.Context, tx *TxContext, stage func(hooks *TxHooks) func(context.Context, *TxContext) error) error {
+	for _, hooks := range svc.options.TxHooks {
+		if fn := stage(hooks); fn != nil {
+			if err := fn(ctx, tx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 执行交易已上链后的阶段，错误只记录日志
+func (svc *lattice) runTxNotifyHooks(ctx context.Context, tx *TxContext, stage func(hooks *TxHooks) func(context.Context, *TxContext) error) {
+	if err := svc.runTxHooks(ctx, tx, stage); err != nil {
+		svc.logger.Warn("Failed to run the transaction hook", "交易钩子执行失败", logger.String("chainId", tx.ChainId), logger.String("owner", tx.Owner), logger.Err(err))
+	}
+}
+
+// 交易失败时执行 OnError，返回原错误
+func (svc *lattice) runTxErrorHooks(ctx context.Context, tx *TxContext, err error) error {
+	tx.Err = err
+	for _, hooks := range svc.options.TxHooks {
+		if hooks.OnError != nil {
+			hooks.OnError(ctx, tx)
+		}
+	}
+	return err
+}
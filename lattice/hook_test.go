@@ -0,0 +1,223 @@
+package lattice
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestLattice_TxHooks(t *testing.T) {
+	httpApi := &recordingHttpApi{conflictHttpApi: conflictHttpApi{chain: &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}}}
+	blockCache := NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
+	blockCache.SetHttpApi(httpApi)
+	signer, err := credentials.GetSigner(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	owner := convert.AddressToZltc(signer.Address())
+	assert.NoError(t, blockCache.SetBlock(chainId, owner, &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}))
+
+	whitelisted := "zltc_dhdfbm9JEoyDvYoCDVsABiZj52TAo9Ei6"
+	var stages []string
+	var lastErr error
+	recorder := &TxHooks{
+		BeforeBuild: func(_ context.Context, tx *TxContext) error {
+			stages = append(stages, "beforeBuild")
+			tx.Request.Payload = constant.ZeroPayload
+			return nil
+		},
+		BeforeSign: func(_ context.Context, tx *TxContext) error {
+			stages = append(stages, "beforeSign")
+			assert.Empty(t, tx.Transaction.Sign)
+			return nil
+		},
+		BeforeSend: func(_ context.Context, tx *TxContext) error {
+			stages = append(stages, "beforeSend")
+			assert.NotEmpty(t, tx.Transaction.Sign)
+			return nil
+		},
+		AfterSend: func(_ context.Context, tx *TxContext) error {
+			stages = append(stages, "afterSend")
+			assert.NotNil(t, tx.Hash)
+			return nil
+		},
+		OnError: func(_ context.Context, tx *TxContext) {
+			stages = append(stages, "onError")
+			lastErr = tx.Err
+		},
+	}
+	svc := &lattice{
+		httpApi:     httpApi,
+		chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1},
+		blockCache:  blockCache,
+		accountLock: NewAccountLock(),
+		options:     &Options{TxHooks: []*TxHooks{NewAmountLimitHook(100), NewLinkerWhitelistHook(whitelisted), recorder}},
+	}
+
+	request := &TxRequest{Type: block.TransactionTypeSend, Linker: whitelisted, Amount: 100}
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"beforeBuild", "beforeSign", "beforeSend", "afterSend"}, stages)
+	assert.Equal(t, constant.ZeroPayload, httpApi.transactions[0].Payload)
+	assert.Empty(t, request.Payload)
+
+	stages = nil
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: whitelisted, Amount: 101})
+	assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+	assert.ErrorIs(t, lastErr, ErrAmountExceedsLimit)
+	assert.Equal(t, []string{"beforeBuild", "beforeSign", "onError"}, stages)
+
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeCallContract, Linker: owner, Code: "0x01"})
+	assert.ErrorIs(t, err, ErrLinkerNotAllowed)
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeSend})
+	assert.ErrorIs(t, err, ErrLinkerNotAllowed)
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: constant.ZeroAddress})
+	assert.ErrorIs(t, err, ErrLinkerNotAllowed)
+
+	// the final transaction is checked, changes made by other hooks are limited as well
+	svc.options.TxHooks = append([]*TxHooks{{
+		BeforeSign: func(_ context.Context, tx *TxContext) error {
+			tx.Transaction.Amount = big.NewInt(1000)
+			tx.Transaction.Linker = owner
+			return nil
+		},
+	}}, svc.options.TxHooks...)
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: whitelisted, Amount: 1})
+	assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+	svc.options.TxHooks = append([]*TxHooks{NewLinkerWhitelistHook(whitelisted)}, svc.options.TxHooks...)
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: whitelisted, Amount: 1})
+	assert.ErrorIs(t, err, ErrLinkerNotAllowed)
+	svc.options.TxHooks = svc.options.TxHooks[2:]
+
+	// deployments are not restricted by the whitelist
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeDeployContract, Code: "0x01"})
+	assert.NoError(t, err)
+	assert.Len(t, httpApi.transactions, 2)
+}
+
+func TestLattice_TxHooks_Modify(t *testing.T) {
+	httpApi := &recordingHttpApi{conflictHttpApi: conflictHttpApi{chain: &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}}}
+	blockCache := NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
+	blockCache.SetHttpApi(httpApi)
+	signer, err := credentials.GetSigner(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	owner := convert.AddressToZltc(signer.Address())
+	assert.NoError(t, blockCache.SetBlock(chainId, owner, &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}))
+
+	code := "0x6080604052"
+	svc := &lattice{
+		httpApi:     httpApi,
+		chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1},
+		blockCache:  blockCache,
+		accountLock: NewAccountLock(),
+		options: &Options{TxHooks: []*TxHooks{{
+			BeforeBuild: func(_ context.Context, tx *TxContext) error {
+				tx.Request.Type = block.TransactionTypeDeployContract
+				return nil
+			},
+			BeforeSign: func(_ context.Context, tx *TxContext) error {
+				tx.Transaction.Code = code
+				return nil
+			},
+		}}},
+	}
+
+	// the type is validated after BeforeBuild and the code hash follows the code set in BeforeSign
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: "unknown", Code: "0x01", Payload: constant.ZeroPayload})
+	assert.NoError(t, err)
+	deploy := httpApi.transactions[0]
+	assert.Equal(t, block.TransactionTypeDeployContract, deploy.Type)
+	assert.Equal(t, crypto.NewCrypto(crypto.Sm2p256v1).Hash(hexutil.MustDecode(code)), deploy.CodeHash)
+}
+
+func TestTransactionPipeline_TxHooks(t *testing.T) {
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}}
+	svc := newPipelineTestLattice(httpApi)
+	var afterSend, onError int
+	svc.options.TxHooks = []*TxHooks{NewAmountLimitHook(100), {
+		AfterSend: func(_ context.Context, tx *TxContext) error {
+			afterSend++
+			assert.NotNil(t, tx.Hash)
+			return nil
+		},
+		OnError: func(_ context.Context, tx *TxContext) {
+			onError++
+			assert.Error(t, tx.Err)
+		},
+	}}
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+	verifyPipelineHash(t, pipeline)
+	httpApi.gate = make(chan struct{})
+
+	// the second transaction is aborted by BeforeSend, the first is sent and the third is rolled back
+	pendings := make([]PendingTransaction, 3)
+	for i, amount := range []uint64{10, 101, 10} {
+		transaction := block.NewTransactionBuilder(block.TransactionTypeSend).
+			SetLinker(credentials.AccountAddress).
+			SetPayload(constant.ZeroPayload).
+			SetAmount(amount).
+			Build()
+		pendings[i], err = pipeline.Submit(context.Background(), transaction)
+		assert.NoError(t, err)
+	}
+	close(httpApi.gate)
+
+	_, err = pendings[0].Wait(context.Background())
+	assert.NoError(t, err)
+	_, err = pendings[1].Wait(context.Background())
+	assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+	_, err = pendings[2].Wait(context.Background())
+	assert.ErrorIs(t, err, ErrPipelineRolledBack)
+	assert.Equal(t, 2, afterSend)
+	assert.Equal(t, 2, onError)
+	assert.Equal(t, uint64(2), httpApi.head.Height)
+}
+
+func TestLattice_BroadcastEnvelope_TxHooks(t *testing.T) {
+	httpApi := &recordingHttpApi{conflictHttpApi: conflictHttpApi{chain: &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}}}
+	var stages []string
+	svc := &lattice{httpApi: httpApi, chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1}, options: &Options{TxHooks: []*TxHooks{NewAmountLimitHook(100), {
+		BeforeSend: func(context.Context, *TxContext) error {
+			stages = append(stages, "beforeSend")
+			return nil
+		},
+		AfterSend: func(context.Context, *TxContext) error {
+			stages = append(stages, "afterSend")
+			return nil
+		},
+		OnError: func(context.Context, *TxContext) {
+			stages = append(stages, "onError")
+		},
+	}}}}
+	signer, err := credentials.GetSigner(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	owner := convert.AddressToZltc(signer.Address())
+
+	for _, amount := range []uint64{101, 10} {
+		transaction := block.NewTransactionBuilder(block.TransactionTypeSend).
+			SetLinker(owner).
+			SetPayload(constant.ZeroPayload).
+			SetAmount(amount).
+			Build()
+		envelope, err := svc.NewUnsignedEnvelope(context.Background(), chainId, owner, transaction)
+		assert.NoError(t, err)
+		assert.NoError(t, envelope.SignWithSigner(signer))
+		_, err = svc.BroadcastEnvelope(context.Background(), envelope)
+		if amount > 100 {
+			assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, []string{"onError", "beforeSend", "afterSend"}, stages)
+	assert.Len(t, httpApi.transactions, 1)
+}
@@ -7,17 +7,19 @@ import (
 	"fmt"
 	"github.com/avast/retry-go"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
 	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"github.com/wylu1037/lattice-go/common/logger"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/crypto"
 	"github.com/wylu1037/lattice-go/lattice/block"
 	"github.com/wylu1037/lattice-go/lattice/client"
 	"github.com/wylu1037/lattice-go/wallet"
 	"net/http"
-	"strconv"
+	"net/url"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -25,77 +27,89 @@ const (
 	httpProtocol              = "http"
 	httpsProtocol             = "https"
 	websocketProtocol         = "ws"
+	websocketsProtocol        = "wss"
 	defaultHttpRequestTimeout = time.Second * 15
+	defaultMaxConflictRetries = 3
 )
 
-// NewLattice 初始化LatticeApi
+// NewLattice 初始化LatticeApi，配置无效时会panic
+//
+// Deprecated: 使用 New，配置无效时返回错误而不是panic
 //
 // Parameters:
 //   - chainConfig *ChainConfig: 链配置信息
 //   - connectingNodeConfig *ConnectingNodeConfig: 节点的连接信息
-//   - blockCache BlockCache: 区块缓存接口，通过缓存支持账户高并发发交易，为nil时，禁用缓存，或着使用内置的 lattice.NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
-//   - accountLock AccountLock: 账户锁接口，通过账户锁支持账户高并发发交易，为nil时，默认使用 lattice.NewAccountLock()
+//   - blockCache BlockCache: 区块缓存接口，通过缓存支持账户高并发发交易，为nil时，禁用缓存，或着使用内置的 lattice.NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)，
+//     多个进程共用账户时使用 lattice.NewRedisBlockCache(rdb, accountLock, nil)
+//   - accountLock AccountLock: 账户锁接口，通过账户锁支持账户高并发发交易，为nil时，默认使用 lattice.NewAccountLock()，
+//     多个进程共用账户时使用 lattice.NewRedisAccountLock(rdb, nil)
 //   - options *Options:
 //
 // Returns:
 //   - Lattice
 func NewLattice(chainConfig *ChainConfig, connectingNodeConfig *ConnectingNodeConfig, blockCache BlockCache, accountLock AccountLock, options *Options) Lattice {
-	if err := chainConfig.validate(); err != nil {
-		panic(err)
-	}
-	if err := connectingNodeConfig.validate(); err != nil {
+	svc, err := New(
+		WithChainConfig(chainConfig),
+		WithNode(connectingNodeConfig),
+		WithBlockCache(blockCache),
+		WithAccountLock(accountLock),
+		WithOptions(options),
+	)
+	if err != nil {
 		panic(err)
 	}
-
-	initHttpClientArgs := &client.HttpApiInitParam{
-		HttpUrl:                    connectingNodeConfig.GetHttpUrl(),
-		GinServerUrl:               connectingNodeConfig.GetGinServerUrl(),
-		Transport:                  options.GetTransport(),
-		JwtSecret:                  connectingNodeConfig.JwtSecret,
-		JwtTokenExpirationDuration: connectingNodeConfig.JwtTokenExpirationDuration,
-	}
-	httpApi := client.NewHttpApi(initHttpClientArgs)
-
-	if blockCache == nil {
-		blockCache = newDisabledMemoryBlockCache(httpApi)
-	} else {
-		blockCache.SetHttpApi(httpApi)
-	}
-
-	if accountLock == nil {
-		accountLock = NewAccountLock()
-	}
-
-	return &lattice{
-		chainConfig:          chainConfig,
-		connectingNodeConfig: connectingNodeConfig,
-		options:              options,
-		httpApi:              httpApi,
-		blockCache:           blockCache,
-		accountLock:          accountLock,
-	}
+	return svc
 }
 
 type lattice struct {
 	httpApi              client.HttpApi        // 节点的http客户端
+	websocketApi         client.WebsocketApi   // 节点的websocket客户端，未配置WebsocketPort时为nil
 	chainConfig          *ChainConfig          // 链信息配置
 	connectingNodeConfig *ConnectingNodeConfig // 节点的连接信息配置
 	blockCache           BlockCache            // 区块缓存接口
 	accountLock          AccountLock           // 账户锁接口
+	receiptWaiter        ReceiptWaiter         // 回执等待器
 	options              *Options              // 可选配置
+	transport            *http.Transport       // New 创建的transport，Close 时关闭其空闲连接，使用调用方的transport时为nil
+	logger               *logger.Helper        // 该实例的日志，为nil时使用进程级日志
+
+	chainConfigsMu sync.RWMutex
+	chains         map[string]*ChainConfig // 注册的各条链的配置，key为链ID
+	chainConfigs   map[string]*ChainConfig // 已生效的链配置，合并了注册的配置和自动发现的配置，key为链ID
 }
 
-// ChainConfig 链配置
+// ChainConfig 链配置，开启自动发现时，Curve可以为空，由节点返回的配置填充
 type ChainConfig struct {
-	Curve     types.Curve // crypto.Secp256k1 or crypto.Sm2p256v1
-	TokenLess bool        // false:有通证链，true:无通证链
+	Curve         types.Curve    // crypto.Secp256k1 or crypto.Sm2p256v1
+	TokenLess     bool           // false:有通证链，true:无通证链
+	Consensus     string         // 共识算法，自动发现时由节点填充
+	Period        uint           // 出块间隔(s)，自动发现时由节点填充
+	Epoch         uint           // 纪元，自动发现时由节点填充
+	DefaultJoule  uint64         // 交易未指定手续费时使用的手续费
+	RetryStrategy *RetryStrategy // 该链默认的等待回执策略，为nil时使用 Options.RetryStrategy
+
+	id uint64 // 解析后的链ID，仅生效的链配置中有值
+}
+
+// 复制链配置，chain为nil时返回空的配置
+func (chain *ChainConfig) clone() *ChainConfig {
+	if chain == nil {
+		return new(ChainConfig)
+	}
+	clone := *chain
+	return &clone
 }
 
 // 验证链配置信息是否有效
-func (chain *ChainConfig) validate() error {
-	if chain.Curve == "" {
+func (chain *ChainConfig) validate(discover bool) error {
+	if chain.Curve == "" && !discover {
 		return fmt.Errorf("ChainConfig未指定Curve参数")
 	}
+	if chain.Curve != "" {
+		if _, err := crypto.GetCrypto(chain.Curve); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -108,6 +122,7 @@ type ConnectingNodeConfig struct {
 	GinHttpPort                uint16
 	JwtSecret                  string
 	JwtTokenExpirationDuration time.Duration
+	TokenProvider              client.TokenProvider // 不为空时代替JwtSecret，如 client.NewJwtWithConfig 使用RS256、ES256、SM2签发，或外部签发的token
 }
 
 // 验证节点的连接信息是否有效
@@ -118,15 +133,21 @@ func (node *ConnectingNodeConfig) validate() error {
 	if node.HttpPort == 0 {
 		return fmt.Errorf("节点的HttpPort信息不能为空")
 	}
+	if _, err := url.Parse(node.GetHttpUrl()); err != nil {
+		return fmt.Errorf("节点的IP信息无效：%w", err)
+	}
 	return nil
 }
 
-// Credentials 凭证配置
+// Credentials 凭证配置，Signer、PrivateKey、FileKey+Passphrase 三选一，优先使用Signer
 type Credentials struct {
-	AccountAddress string // 账户地址
-	Passphrase     string // 身份密码
-	FileKey        string // FileKey 的json字符串
-	PrivateKey     string // 私钥
+	AccountAddress string        // 账户地址
+	Passphrase     string        // 身份密码
+	FileKey        string        // FileKey 的json字符串
+	PrivateKey     string        // 私钥
+	Signer         crypto.Signer // 签名者，私钥保存在HSM、KMS或远程签名服务中时使用
+
+	signerMu sync.Mutex // 保护 GetSigner 对 Signer 的延迟初始化，凭证可以被多个协程同时使用
 }
 
 type Options struct {
@@ -142,20 +163,84 @@ type Options struct {
 	// (keep-alive) connections to keep per-host.
 	// If zero, DefaultMaxIdleConnsPerHost(2) is used.
 	MaxIdleConnsPerHost int
+
+	// ReceiptPollInterval 等待回执时轮询节点的间隔，同一条链上所有等待中的交易共用一次轮询，默认200ms
+	ReceiptPollInterval time.Duration
+
+	// Nodes 除ConnectingNodeConfig外的其它节点，配置后http请求会按照RoutingPolicy在所有节点间路由，
+	// 并在网络错误时切换到其它节点，jwt信息使用ConnectingNodeConfig的配置
+	Nodes []*ConnectingNodeConfig
+
+	// RoutingPolicy 多节点的路由策略，默认为 client.RoutingPolicyStickyPerAccount，保证同一账户的区块缓存与节点一致
+	RoutingPolicy client.RoutingPolicy
+
+	// HealthCheckInterval 多节点的健康检查间隔，默认10s
+	HealthCheckInterval time.Duration
+
+	// MaxConflictRetries 缓存的区块高度或父哈希与链上不一致时，刷新区块并重新发送交易的最大次数，默认3次，<0时不重试
+	MaxConflictRetries int
+
+	// HeightConflictCodes 节点表示区块高度或父哈希与链上不一致的错误码，需要按节点实际返回的错误码配置，
+	// 未配置时只有通过 client.RegisterRpcErrorCode 识别为 errs.ErrHeightConflict 的错误才会刷新区块并重新发送
+	HeightConflictCodes []int16
+
+	// HttpRequestTimeout 单次请求节点的超时时间，默认15s
+	HttpRequestTimeout time.Duration
+
+	// RetryStrategy 默认的等待回执策略，等待回执时未指定策略则使用该策略
+	RetryStrategy *RetryStrategy
+
+	// Chains 各条链的配置，key为链ID，未配置的链使用ChainConfig，也可以通过 Lattice.RegisterChain 注册
+	Chains map[string]*ChainConfig
+
+	// TxHooks 交易生命周期的钩子，按顺序执行，作用于 Lattice.SendTransaction 以及转账、部署/调用/升级合约等方法
+	TxHooks []*TxHooks
+
+	// DiscoverChainConfig 是否从节点自动发现链配置，开启后每个链ID首次使用时查询节点的曲线、是否无通证等配置并缓存，
+	// 配置的曲线与节点不一致时返回 ErrCurveMismatch
+	DiscoverChainConfig bool
+}
+
+func (options *Options) httpRequestTimeout() time.Duration {
+	if options.HttpRequestTimeout <= 0 {
+		return defaultHttpRequestTimeout
+	}
+	return options.HttpRequestTimeout
+}
+
+// 错误是否表示区块高度或父哈希与链上不一致
+func (options *Options) isHeightConflict(err error) bool {
+	if errors.Is(err, errs.ErrHeightConflict) {
+		return true
+	}
+	var rpcErr *client.RpcError
+	return errors.As(err, &rpcErr) && slices.Contains(options.HeightConflictCodes, rpcErr.Code)
+}
+
+func (options *Options) maxConflictRetries() int {
+	switch {
+	case options.MaxConflictRetries == 0:
+		return defaultMaxConflictRetries
+	case options.MaxConflictRetries < 0:
+		return 0
+	default:
+		return options.MaxConflictRetries
+	}
 }
 
+// GetTransport 获取http连接的transport，未配置Transport时根据连接配置创建一个新的，不会修改 Options
 func (options *Options) GetTransport() *http.Transport {
-	if options.Transport == nil {
-		options.Transport = &http.Transport{
-			TLSClientConfig:     &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify},
-			MaxIdleConns:        options.MaxIdleConns,
-			MaxIdleConnsPerHost: options.MaxIdleConnsPerHost,
-		}
+	if options.Transport != nil {
+		return options.Transport
+	}
+	return &http.Transport{
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify},
+		MaxIdleConns:        options.MaxIdleConns,
+		MaxIdleConnsPerHost: options.MaxIdleConnsPerHost,
 	}
-	return options.Transport
 }
 
-// GetSK 获取私钥的Hex字符串
+// GetSK 获取私钥的Hex字符串，私钥会以字符串形式缓存在凭证中，建议使用 GetSigner
 //
 // Returns:
 //   - string: 私钥的hex字符串
@@ -179,12 +264,42 @@ func (credentials *Credentials) GetSK() (string, error) {
 	return credentials.PrivateKey, nil
 }
 
+// GetSigner 获取签名者，未配置Signer时根据私钥或FileKey创建，并缓存在凭证中，不会以字符串形式保留私钥，可以并发调用
+//
+// Parameters:
+//   - curve types.Curve: 链的椭圆曲线，签名者的曲线与之不一致时返回错误
+//
+// Returns:
+//   - crypto.Signer
+//   - error
+func (credentials *Credentials) GetSigner(curve types.Curve) (crypto.Signer, error) {
+	credentials.signerMu.Lock()
+	defer credentials.signerMu.Unlock()
+	if credentials.Signer == nil {
+		var signer crypto.Signer
+		var err error
+		if credentials.PrivateKey != "" {
+			signer, err = crypto.NewMemorySignerFromHex(curve, credentials.PrivateKey)
+		} else {
+			signer, err = wallet.NewFileKey(credentials.FileKey).Signer(credentials.Passphrase)
+		}
+		if err != nil {
+			return nil, err
+		}
+		credentials.Signer = signer
+	}
+	if credentials.Signer.Curve() != curve {
+		return nil, fmt.Errorf("the curve of signer %s does not match the chain curve %s", credentials.Signer.Curve(), curve)
+	}
+	return credentials.Signer, nil
+}
+
 func (node *ConnectingNodeConfig) GetHttpUrl() string {
 	return fmt.Sprintf("%s://%s:%d", lo.Ternary(node.Insecure, httpsProtocol, httpProtocol), node.Ip, node.HttpPort)
 }
 
 func (node *ConnectingNodeConfig) GetWebsocketUrl() string {
-	return fmt.Sprintf("%s://%s:%d", websocketProtocol, node.Ip, node.WebsocketPort)
+	return fmt.Sprintf("%s://%s:%d", lo.Ternary(node.Insecure, websocketsProtocol, websocketProtocol), node.Ip, node.WebsocketPort)
 }
 
 func (node *ConnectingNodeConfig) GetGinServerUrl() string {
@@ -286,6 +401,25 @@ func DefaultRandomRetryStrategy() *RetryStrategy {
 	}
 }
 
+// 按照策略的重试次数和间隔估算等待的最长时长，无法估算时返回0
+func (strategy *RetryStrategy) maxWaitDuration() time.Duration {
+	if strategy == nil || strategy.Attempts == 0 {
+		return 0
+	}
+	switch strategy.Strategy {
+	case BackOff:
+		// retry.BackOffDelay: delay * 2^n, n为已重试的次数
+		shift := min(strategy.Attempts-1, 32)
+		return max(strategy.Delay*time.Duration((uint64(1)<<shift)-1), strategy.Delay)
+	case FixedInterval:
+		return strategy.Delay * time.Duration(strategy.Attempts)
+	case RandomInterval:
+		return (strategy.Delay + strategy.MaxJitter) * time.Duration(strategy.Attempts)
+	default:
+		return 0
+	}
+}
+
 func (strategy *RetryStrategy) BackOffOpts() []retry.Option {
 	return []retry.Option{retry.Attempts(strategy.Attempts), retry.Delay(strategy.Delay), retry.DelayType(retry.BackOffDelay)}
 }
@@ -307,6 +441,96 @@ type Lattice interface {
 	//   - client.HttpApi
 	HttpApi() client.HttpApi
 
+	// WebsocketApi return the websocket api, nil when ConnectingNodeConfig.WebsocketPort is not configured
+	//
+	// Parameters:
+	//
+	// Returns:
+	//   - client.WebsocketApi
+	WebsocketApi() client.WebsocketApi
+
+	// Close 关闭Lattice，停止节点池的健康检查、回执等待器和websocket连接，等待中的回执返回 ErrReceiptWaiterClosed，
+	// 关闭后不能再使用
+	//
+	// Parameters:
+	//
+	// Returns:
+	//   - error
+	Close() error
+
+	// RegisterChain 注册链的配置，同一个Lattice可以服务主链和多条子链，未注册的链使用默认的ChainConfig
+	//
+	// Parameters:
+	//   - chainId string
+	//   - chainConfig *ChainConfig: 开启 Options.DiscoverChainConfig 时Curve可以为空
+	//
+	// Returns:
+	//   - error
+	RegisterChain(chainId string, chainConfig *ChainConfig) error
+
+	// GetChainConfig 获取链生效的配置，优先使用注册的配置，开启 Options.DiscoverChainConfig 时，每个链ID首次使用时从节点查询并缓存
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//
+	// Returns:
+	//   - *ChainConfig
+	//   - error: 配置的曲线与节点不一致时为 ErrCurveMismatch
+	GetChainConfig(ctx context.Context, chainId string) (*ChainConfig, error)
+
+	// NewTransactionPipeline 创建账户的交易流水线，在一次网络往返中发送多笔交易
+	//
+	// Parameters:
+	//   - credentials *Credentials: 账户凭证
+	//   - chainId string
+	//   - options *PipelineOptions: 为nil时使用默认配置
+	//
+	// Returns:
+	//   - TransactionPipeline
+	//   - error
+	NewTransactionPipeline(credentials *Credentials, chainId string, options *PipelineOptions) (TransactionPipeline, error)
+
+	// NewUnsignedEnvelope 在线查询账户的最新区块，构造用于离线签名的交易信封
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//   - accountAddress string: 签名账户的地址
+	//   - transaction *block.Transaction: 未签名的交易，Height、ParentHash、DaemonHash、Owner由该方法设置，CodeHash根据Code计算
+	//
+	// Returns:
+	//   - *block.Envelope
+	//   - error
+	NewUnsignedEnvelope(ctx context.Context, chainId, accountAddress string, transaction *block.Transaction) (*block.Envelope, error)
+
+	// BroadcastEnvelope 广播离线签名后的交易信封，签名在本地验证不通过或无法验证时不广播
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - envelope *block.Envelope: 已签名的交易信封
+	//
+	// Returns:
+	//   - *common.Hash: 交易哈希
+	//   - error
+	BroadcastEnvelope(ctx context.Context, envelope *block.Envelope) (*common.Hash, error)
+
+	// SendTransaction 发起任意类型的交易，签名者的地址作为交易的Owner，Code不为空时自动计算CodeHash，
+	// 转账、部署/调用/升级合约，以及吊销、冻结、解冻合约都通过该方法发送
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - signer crypto.Signer: 签名者，曲线需要与链一致
+	//   - chainId string: 链ID
+	//   - request *TxRequest: 交易请求
+	//   - opts ...SendOption: 可选项，如 WithWaitReceipt 等待回执
+	//
+	// Returns:
+	//   - *common.Hash: 交易哈希
+	//   - *types.Receipt: 回执，未等待回执时为nil
+	//   - error
+	SendTransaction(ctx context.Context, signer crypto.Signer, chainId string, request *TxRequest, opts ...SendOption) (*common.Hash, *types.Receipt, error)
+
 	// Transfer 发起转账交易
 	//
 	// Parameters:
@@ -503,193 +727,135 @@ func (svc *lattice) HttpApi() client.HttpApi {
 	return svc.httpApi
 }
 
-// Start handle transaction, contains
-// 1.Sign transaction,
-// 2.Send transaction to the chain.
-func (svc *lattice) handleTransaction(ctx context.Context, credentials *Credentials, chainId string, transaction *block.Transaction, latestBlock *types.LatestBlock) (*common.Hash, error) {
-	chainIdAsInt, err := strconv.Atoi(chainId)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	}
-	sk, err := credentials.GetSK()
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	}
-	err = transaction.SignTX(uint64(chainIdAsInt), svc.chainConfig.Curve, sk)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	}
-
-	cancelCtx, cancelFunc := context.WithTimeout(ctx, defaultHttpRequestTimeout)
-	defer cancelFunc()
-	hash, err := svc.httpApi.SendSignedTransaction(cancelCtx, chainId, transaction)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	} else {
-		latestBlock.Hash = *hash
-		latestBlock.IncrHeight()
-		if err := svc.blockCache.SetBlock(chainId, credentials.AccountAddress, latestBlock); err != nil {
-			log.Error().Err(err)
-		}
-	}
-	return hash, nil
+func (svc *lattice) WebsocketApi() client.WebsocketApi {
+	return svc.websocketApi
 }
 
-func (svc *lattice) Transfer(ctx context.Context, credentials *Credentials, chainId, linker, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起转账交易，chainId: %s, linker: %s, payload: %s, amount: %d, joule: %d", chainId, linker, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
+func (svc *lattice) Close() error {
+	svc.receiptWaiter.Close()
+	svc.httpApi.Close()
+	if svc.websocketApi != nil {
+		svc.websocketApi.Close()
 	}
-
-	transaction := block.NewTransactionBuilder(block.TransactionTypeSend).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(linker).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
-
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
+	if svc.transport != nil {
+		svc.transport.CloseIdleConnections()
 	}
-	log.Debug().Msgf("结束转账交易，哈希为：%s", hash.String())
-	return hash, nil
+	return nil
 }
 
-func (svc *lattice) DeployContract(ctx context.Context, credentials *Credentials, chainId, data, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起部署合约交易，chainId: %s, data: %s, payload: %s, amount: %d, joule: %d", chainId, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	}
+// Start handle transaction, contains
+// 1.Sign transaction,
+// 2.Send transaction to the chain,
+// 3.When the cached latest block conflicts with the chain, refresh it, re-sign and resend.
+// The BeforeSend hooks run before every send.
+func (svc *lattice) handleTransaction(ctx context.Context, chainConfig *ChainConfig, signer crypto.Signer, tx *TxContext, latestBlock *types.LatestBlock) (*common.Hash, error) {
+	chainId, owner, transaction := tx.ChainId, tx.Owner, tx.Transaction
+	// 与区块缓存的查询路由到同一个节点
+	ctx = client.WithAccountRouting(ctx, chainId, owner)
+	for attempt := 0; ; attempt++ {
+		err := transaction.SignTXWithSigner(chainConfig.id, signer)
+		if err != nil {
+			svc.logger.Error("Failed to sign the transaction", "签名交易失败", logger.String("chainId", chainId), logger.String("owner", owner), logger.Err(err))
+			return nil, err
+		}
 
-	transaction := block.NewTransactionBuilder(block.TransactionTypeDeployContract).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(constant.ZeroAddress).
-		SetCode(data).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
+		if err := svc.runTxHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.BeforeSend }); err != nil {
+			svc.logger.Error("The transaction was aborted by a hook", "交易被钩子中止", logger.String("chainId", chainId), logger.String("owner", owner), logger.Err(err))
+			return nil, err
+		}
 
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(data))
-	transaction.CodeHash = dataHash
+		cancelCtx, cancelFunc := context.WithTimeout(ctx, svc.options.httpRequestTimeout())
+		hash, err := svc.httpApi.SendSignedTransaction(cancelCtx, chainId, transaction)
+		cancelFunc()
+		if err == nil {
+			latestBlock.Hash = *hash
+			latestBlock.IncrHeight()
+			if err := svc.blockCache.SetBlock(chainId, owner, latestBlock); err != nil {
+				svc.logger.Error("Failed to update the block cache", "更新区块缓存失败", logger.String("chainId", chainId), logger.String("accountAddress", owner), logger.Err(err))
+			}
+			return hash, nil
+		}
+		if !svc.options.isHeightConflict(err) || attempt >= svc.options.maxConflictRetries() {
+			svc.logger.Error("Failed to send the transaction", "发送交易失败", logger.String("chainId", chainId), logger.String("owner", owner), logger.Err(err))
+			return nil, err
+		}
 
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
+		svc.logger.Warn("The block height of the account conflicts with the chain, refreshing the latest block and resending", "账户的区块高度与链上不一致，刷新最新区块后重新发送交易", logger.String("chainId", chainId), logger.String("owner", owner), logger.Int("attempt", attempt+1), logger.Err(err))
+		if latestBlock, err = svc.refreshLatestBlock(ctx, chainId, owner); err != nil {
+			return nil, err
+		}
+		transaction.Height = latestBlock.Height + 1
+		transaction.ParentHash = latestBlock.Hash
+		transaction.DaemonHash = latestBlock.DaemonBlockHash
 	}
-	log.Debug().Msgf("结束部署合约，哈希为：%s", hash.String())
-	return hash, nil
 }
 
-func (svc *lattice) CallContract(ctx context.Context, credentials *Credentials, chainId, contractAddress, data, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起调用合约交易，chainId: %s, contractAddress: %s, data: %s, payload: %s, amount: %d, joule: %d", chainId, contractAddress, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
+// 从节点查询账户包括pending交易在内的最新区块，并覆盖区块缓存
+func (svc *lattice) refreshLatestBlock(ctx context.Context, chainId, accountAddress string) (*types.LatestBlock, error) {
+	cancelCtx, cancelFunc := context.WithTimeout(ctx, svc.options.httpRequestTimeout())
+	defer cancelFunc()
+	latestBlock, err := svc.httpApi.GetLatestBlockWithPending(cancelCtx, chainId, accountAddress)
 	if err != nil {
+		svc.logger.Error("Failed to refresh the latest block of the account", "刷新账户的最新区块失败", logger.String("chainId", chainId), logger.String("accountAddress", accountAddress), logger.Err(err))
 		return nil, err
 	}
+	if err := svc.blockCache.SetBlock(chainId, accountAddress, latestBlock); err != nil {
+		svc.logger.Error("Failed to update the block cache", "更新区块缓存失败", logger.String("chainId", chainId), logger.String("accountAddress", accountAddress), logger.Err(err))
+	}
+	return latestBlock, nil
+}
 
-	transaction := block.NewTransactionBuilder(block.TransactionTypeCallContract).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(contractAddress).
-		SetCode(data).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
+func (svc *lattice) Transfer(ctx context.Context, credentials *Credentials, chainId, linker, payload string, amount, joule uint64) (*common.Hash, error) {
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: linker, Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
+}
 
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(data))
-	transaction.CodeHash = dataHash
+func (svc *lattice) DeployContract(ctx context.Context, credentials *Credentials, chainId, data, payload string, amount, joule uint64) (*common.Hash, error) {
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployContract, Code: data, Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
+}
 
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
-	}
-	log.Debug().Msgf("结束调用合约，哈希为：%s", hash.String())
-	return hash, nil
+func (svc *lattice) CallContract(ctx context.Context, credentials *Credentials, chainId, contractAddress, data, payload string, amount, joule uint64) (*common.Hash, error) {
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallContract, Linker: contractAddress, Code: data, Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) waitReceipt(ctx context.Context, chainId string, hash *common.Hash, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	var err error
-	var receipt *types.Receipt
-	err = retry.Do(
-		func() error {
-			receipt, err = svc.httpApi.GetReceipt(ctx, chainId, hash.String())
-			if err != nil {
-				log.Error().Err(err)
-				return err
-			}
-			if receipt.DBlockNumber == 0 {
-				log.Error().Msgf("交易【%s】的回执的守护区块为空", hash.String())
-				return errors.New("交易的回执的守护区块为空")
-			}
-			return nil
-		},
-		retryStrategy.GetRetryStrategyOpts()...,
-	)
+	if retryStrategy == nil {
+		if chainConfig, err := svc.GetChainConfig(ctx, chainId); err == nil && chainConfig.RetryStrategy != nil {
+			retryStrategy = chainConfig.RetryStrategy
+		} else {
+			retryStrategy = svc.options.RetryStrategy
+		}
+	}
+	if maxWait := retryStrategy.maxWaitDuration(); maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
 
+	receipt, err := svc.receiptWaiter.Wait(ctx, chainId, *hash)
 	if err != nil {
-		log.Error().Err(err)
+		svc.logger.Error("Failed to wait for the receipt", "等待交易的回执失败", logger.String("chainId", chainId), logger.Stringer("hash", hash), logger.Err(err))
 		return hash, nil, err
 	}
 	return hash, receipt, nil
 }
 
 func (svc *lattice) TransferWaitReceipt(ctx context.Context, credentials *Credentials, chainId, linker, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.Transfer(ctx, credentials, chainId, linker, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeSend, Linker: linker, Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) DeployContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, data, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.DeployContract(ctx, credentials, chainId, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployContract, Code: data, Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) CallContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress, data, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.CallContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallContract, Linker: contractAddress, Code: data, Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) PreCallContract(ctx context.Context, chainId, owner, contractAddress, data, payload string) (*types.Receipt, error) {
-	log.Debug().Msgf("开始发起预调用合约交易，chainId: %s, owner: %s, contractAddress: %s, data: %s, payload: %s", chainId, owner, contractAddress, data, payload)
+	svc.logger.Debug("Start pre-calling the contract", "开始发起预调用合约交易", logger.String("chainId", chainId), logger.String("owner", owner), logger.String("contractAddress", contractAddress), logger.String("data", data), logger.String("payload", payload))
 
 	transaction := block.NewTransactionBuilder(block.TransactionTypeCallContract).
 		SetLatestBlock(
@@ -708,253 +874,69 @@ func (svc *lattice) PreCallContract(ctx context.Context, chainId, owner, contrac
 	if err != nil {
 		return nil, err
 	}
-	log.Debug().Msgf("结束预调用合约，回执为：%+v", receipt)
+	svc.logger.Debug("Finish pre-calling the contract", "结束预调用合约", logger.Bool("success", receipt.Success), logger.Uint64("jouleUsed", receipt.JouleUsed))
 	return receipt, nil
 }
 
 func (svc *lattice) UpgradeContract(ctx context.Context, credentials *Credentials, chainId, contractAddress, data, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起升级合约交易，chainId: %s, contractAddress: %s, data: %s, payload: %s, amount: %d, joule: %d", chainId, contractAddress, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		return nil, err
-	}
-
-	transaction := block.NewTransactionBuilder(block.TransactionTypeUpgradeContract).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(contractAddress).
-		SetCode(data).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
-
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(data))
-	transaction.CodeHash = dataHash
-
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
-	}
-	log.Debug().Msgf("结束升级合约，哈希为：%s", hash.String())
-	return hash, nil
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeContract, Linker: contractAddress, Code: data, Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) UpgradeContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress, data, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.UpgradeContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
-}
-
-func (svc *lattice) deployMultilingualContract(ctx context.Context, credentials *Credentials, chainId string, lang types.ContractLang, data types.DeployMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起部署%s合约交易，chainId: %s, data: %+v, payload: %s, amount: %d, joule: %d", lang, chainId, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		log.Error().Err(err)
-		return nil, err
-	}
-
-	var transactionType block.TransactionType
-	switch lang {
-	case types.ContractLangGo:
-		transactionType = block.TransactionTypeDeployGoContract
-	case types.ContractLangJava:
-		transactionType = block.TransactionTypeDeployJavaContract
-	default:
-	}
-	code := data.Encode()
-	transaction := block.NewTransactionBuilder(transactionType).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(constant.ZeroAddress).
-		SetCode(code).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
-
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(code))
-	transaction.CodeHash = dataHash
-
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
-	}
-	log.Debug().Msgf("结束部署%s合约，哈希为：%s", lang, hash.String())
-	return hash, nil
-}
-
-func (svc *lattice) upgradeMultilingualContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, lang types.ContractLang, data types.UpgradeMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起升级%s合约交易，chainId: %s, contractAddress: %s, data: %s, payload: %s, amount: %d, joule: %d", lang, chainId, contractAddress, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		return nil, err
-	}
-
-	var transactionType block.TransactionType
-	switch lang {
-	case types.ContractLangGo:
-		transactionType = block.TransactionTypeUpgradeGoContract
-	case types.ContractLangJava:
-		transactionType = block.TransactionTypeUpgradeJavaContract
-	default:
-	}
-	code := data.Encode()
-	transaction := block.NewTransactionBuilder(transactionType).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(contractAddress).
-		SetCode(code).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
-
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(code))
-	transaction.CodeHash = dataHash
-
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
-	}
-	log.Debug().Msgf("结束升级%s合约，哈希为：%s", lang, hash.String())
-	return hash, nil
-}
-
-func (svc *lattice) callMultilingualContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, lang types.ContractLang, data types.CallMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	log.Debug().Msgf("开始发起调用%s合约交易，chainId: %s, contractAddress: %s, data: %s, payload: %s, amount: %d, joule: %d", lang, chainId, contractAddress, data, payload, amount, joule)
-
-	svc.accountLock.Obtain(chainId, credentials.AccountAddress)
-	defer svc.accountLock.Unlock(chainId, credentials.AccountAddress)
-
-	latestBlock, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
-	if err != nil {
-		return nil, err
-	}
-
-	var transactionType block.TransactionType
-	switch lang {
-	case types.ContractLangGo:
-		transactionType = block.TransactionTypeCallGoContract
-	case types.ContractLangJava:
-		transactionType = block.TransactionTypeCallJavaContract
-	default:
-	}
-	code := data.Encode()
-	transaction := block.NewTransactionBuilder(transactionType).
-		SetLatestBlock(latestBlock).
-		SetOwner(credentials.AccountAddress).
-		SetLinker(contractAddress).
-		SetCode(code).
-		SetPayload(payload).
-		SetAmount(amount).
-		SetJoule(joule).
-		Build()
-
-	cryptoInstance := crypto.NewCrypto(svc.chainConfig.Curve)
-	dataHash := cryptoInstance.Hash(hexutil.MustDecode(code))
-	transaction.CodeHash = dataHash
-
-	hash, err := svc.handleTransaction(ctx, credentials, chainId, transaction, latestBlock)
-	if err != nil {
-		return nil, err
-	}
-	log.Debug().Msgf("结束调用%s合约，哈希为：%s", lang, hash.String())
-	return hash, nil
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeContract, Linker: contractAddress, Code: data, Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) DeployGoContract(ctx context.Context, credentials *Credentials, chainId string, data types.DeployMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.deployMultilingualContract(ctx, credentials, chainId, types.ContractLangGo, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployGoContract, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) UpgradeGoContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.UpgradeMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.upgradeMultilingualContract(ctx, credentials, chainId, contractAddress, types.ContractLangGo, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeGoContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) CallGoContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.CallMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.callMultilingualContract(ctx, credentials, chainId, contractAddress, types.ContractLangGo, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallGoContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) DeployJavaContract(ctx context.Context, credentials *Credentials, chainId string, data types.DeployMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.deployMultilingualContract(ctx, credentials, chainId, types.ContractLangJava, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployJavaContract, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) UpgradeJavaContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.UpgradeMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.upgradeMultilingualContract(ctx, credentials, chainId, contractAddress, types.ContractLangJava, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeJavaContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) CallJavaContract(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.CallMultilingualContractCode, payload string, amount, joule uint64) (*common.Hash, error) {
-	return svc.callMultilingualContract(ctx, credentials, chainId, contractAddress, types.ContractLangJava, data, payload, amount, joule)
+	hash, _, err := svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallJavaContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule})
+	return hash, err
 }
 
 func (svc *lattice) DeployGoContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId string, data types.DeployMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.DeployGoContract(ctx, credentials, chainId, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployGoContract, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) UpgradeGoContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.UpgradeMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.UpgradeGoContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeGoContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) CallGoContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.CallMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.CallGoContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallGoContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) DeployJavaContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId string, data types.DeployMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.DeployJavaContract(ctx, credentials, chainId, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeDeployJavaContract, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) UpgradeJavaContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.UpgradeMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.UpgradeJavaContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeUpgradeJavaContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
 
 func (svc *lattice) CallJavaContractWaitReceipt(ctx context.Context, credentials *Credentials, chainId, contractAddress string, data types.CallMultilingualContractCode, payload string, amount, joule uint64, retryStrategy *RetryStrategy) (*common.Hash, *types.Receipt, error) {
-	hash, err := svc.CallJavaContract(ctx, credentials, chainId, contractAddress, data, payload, amount, joule)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return svc.waitReceipt(ctx, chainId, hash, retryStrategy)
+	return svc.sendTransactionWithCredentials(ctx, credentials, chainId, &TxRequest{Type: block.TransactionTypeCallJavaContract, Linker: contractAddress, Code: data.Encode(), Payload: payload, Amount: amount, Joule: joule}, WithWaitReceipt(retryStrategy))
 }
@@ -3,15 +3,20 @@ package lattice
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/wylu1037/lattice-go/abi"
 	"github.com/wylu1037/lattice-go/common/constant"
 	"github.com/wylu1037/lattice-go/common/convert"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/block"
 	"github.com/wylu1037/lattice-go/lattice/builtin"
+	"github.com/wylu1037/lattice-go/lattice/client"
 	"github.com/wylu1037/lattice-go/lattice/protobuf"
+	"math/big"
 	"strconv"
 	"strings"
 	"sync"
@@ -218,3 +223,175 @@ func TestLattice_JsonRpc(t *testing.T) {
 	assert.NoError(t, err)
 	t.Log(proposal)
 }
+
+// 模拟节点表示高度冲突的错误码
+const testHeightConflictCode int16 = -32010
+
+// 模拟节点，只接受高度和父哈希与链上一致的交易
+type conflictHttpApi struct {
+	client.HttpApi
+	mu    sync.Mutex
+	chain *types.LatestBlock
+	sends int
+}
+
+func (api *conflictHttpApi) SendSignedTransaction(_ context.Context, _ string, transaction *block.Transaction) (*common.Hash, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.sends++
+	if transaction.Height != api.chain.Height+1 || transaction.ParentHash != api.chain.Hash {
+		return nil, (&client.JsonRpcError{Code: testHeightConflictCode, Message: "invalid height or parent hash"}).Error()
+	}
+	hash := common.BigToHash(new(big.Int).SetUint64(transaction.Height))
+	api.chain.Height, api.chain.Hash = transaction.Height, hash
+	return &hash, nil
+}
+
+func (api *conflictHttpApi) GetLatestBlockWithPending(context.Context, string, string) (*types.LatestBlock, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	latestBlock := *api.chain
+	return &latestBlock, nil
+}
+
+func TestLattice_HandleTransactionConflict(t *testing.T) {
+	httpApi := &conflictHttpApi{chain: &types.LatestBlock{Height: 5, Hash: common.HexToHash("0x05")}}
+	blockCache := NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
+	blockCache.SetHttpApi(httpApi)
+	// the cached block is stale, another process has sent transactions from the same account
+	assert.NoError(t, blockCache.SetBlock(chainId, credentials.AccountAddress, &types.LatestBlock{Height: 2, Hash: common.HexToHash("0x02")}))
+
+	svc := &lattice{
+		httpApi:     httpApi,
+		chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1},
+		blockCache:  blockCache,
+		accountLock: NewAccountLock(),
+		options:     &Options{HeightConflictCodes: []int16{testHeightConflictCode}},
+	}
+	hash, err := svc.Transfer(context.Background(), credentials, chainId, credentials.AccountAddress, constant.ZeroPayload, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(6)), *hash)
+	assert.Equal(t, 2, httpApi.sends)
+
+	cached, err := blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), cached.Height)
+
+	// retries disabled, the node's error is returned
+	svc.options.MaxConflictRetries = -1
+	httpApi.chain.Height = 10
+	_, err = svc.Transfer(context.Background(), credentials, chainId, credentials.AccountAddress, constant.ZeroPayload, 0, 0)
+	var rpcErr *client.RpcError
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, testHeightConflictCode, rpcErr.Code)
+	assert.Equal(t, 3, httpApi.sends)
+
+	// the code is not configured as a conflict, no retry
+	svc.options.MaxConflictRetries = 0
+	svc.options.HeightConflictCodes = nil
+	_, err = svc.Transfer(context.Background(), credentials, chainId, credentials.AccountAddress, constant.ZeroPayload, 0, 0)
+	assert.Error(t, err)
+	assert.Equal(t, 4, httpApi.sends)
+}
+
+type recordingHttpApi struct {
+	conflictHttpApi
+	transactions []*block.Transaction
+}
+
+func (api *recordingHttpApi) SendSignedTransaction(ctx context.Context, chainId string, transaction *block.Transaction) (*common.Hash, error) {
+	api.transactions = append(api.transactions, transaction)
+	return api.conflictHttpApi.SendSignedTransaction(ctx, chainId, transaction)
+}
+
+func TestLattice_SendTransaction(t *testing.T) {
+	httpApi := &recordingHttpApi{conflictHttpApi: conflictHttpApi{chain: &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}}}
+	blockCache := NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
+	blockCache.SetHttpApi(httpApi)
+	svc := &lattice{
+		httpApi:     httpApi,
+		chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1},
+		blockCache:  blockCache,
+		accountLock: NewAccountLock(),
+		options:     &Options{},
+	}
+	signer, err := credentials.GetSigner(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	owner := convert.AddressToZltc(signer.Address())
+	assert.NoError(t, blockCache.SetBlock(chainId, owner, &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}))
+
+	code := "0x6080604052"
+	_, receipt, err := svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeDeployContract, Code: code, Payload: constant.ZeroPayload})
+	assert.NoError(t, err)
+	assert.Nil(t, receipt)
+	deploy := httpApi.transactions[0]
+	assert.Equal(t, constant.ZeroAddress, deploy.Linker)
+	assert.Equal(t, crypto.NewCrypto(crypto.Sm2p256v1).Hash(hexutil.MustDecode(code)), deploy.CodeHash)
+	assert.Equal(t, owner, deploy.Owner)
+
+	contractAddress := "zltc_QLbz7JHiBTspS962RLKV8GndWFwjA5K66"
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: block.TransactionTypeRevokeContract, Linker: contractAddress, Payload: constant.ZeroPayload})
+	assert.NoError(t, err)
+	revoke := httpApi.transactions[1]
+	assert.Equal(t, block.TransactionTypeRevokeContract, revoke.Type)
+	assert.Equal(t, contractAddress, revoke.Linker)
+	assert.Equal(t, common.Hash{}, revoke.CodeHash)
+	assert.Equal(t, uint64(3), revoke.Height)
+
+	_, _, err = svc.SendTransaction(context.Background(), signer, chainId, &TxRequest{Type: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestLattice_BroadcastEnvelope(t *testing.T) {
+	httpApi := &recordingHttpApi{conflictHttpApi: conflictHttpApi{chain: &types.LatestBlock{Height: 1, Hash: common.HexToHash("0x01")}}}
+	svc := &lattice{httpApi: httpApi, chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1}, options: &Options{}}
+	signer, err := credentials.GetSigner(crypto.Sm2p256v1)
+	assert.NoError(t, err)
+	owner := convert.AddressToZltc(signer.Address())
+
+	code := "0x6080604052"
+	transaction := block.NewTransactionBuilder(block.TransactionTypeDeployContract).
+		SetLinker(constant.ZeroAddress).
+		SetCode(code).
+		SetPayload(constant.ZeroPayload).
+		Build()
+	envelope, err := svc.NewUnsignedEnvelope(context.Background(), chainId, owner, transaction)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.NewCrypto(crypto.Sm2p256v1).Hash(hexutil.MustDecode(code)), envelope.Transaction.CodeHash)
+	assert.NoError(t, envelope.SignWithSigner(signer))
+
+	// a signature that cannot be verified locally is not broadcast
+	sign := envelope.Transaction.Sign
+	envelope.Transaction.Sign = "0x1234"
+	_, err = svc.BroadcastEnvelope(context.Background(), envelope)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	assert.Empty(t, httpApi.transactions)
+
+	envelope.Transaction.Sign = sign
+	_, err = svc.BroadcastEnvelope(context.Background(), envelope)
+	assert.NoError(t, err)
+	assert.Len(t, httpApi.transactions, 1)
+}
+
+func TestCredentials_GetSigner(t *testing.T) {
+	c := &Credentials{AccountAddress: credentials.AccountAddress, PrivateKey: credentials.PrivateKey}
+	signers := make([]crypto.Signer, 8)
+	var wg sync.WaitGroup
+	for i := range signers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			signer, err := c.GetSigner(crypto.Sm2p256v1)
+			assert.NoError(t, err)
+			signers[i] = signer
+		}(i)
+	}
+	wg.Wait()
+	for _, signer := range signers {
+		assert.Same(t, signers[0], signer)
+	}
+	assert.Equal(t, c.AccountAddress, convert.AddressToZltc(signers[0].Address()))
+
+	_, err := c.GetSigner(crypto.Secp256k1)
+	assert.Error(t, err)
+}
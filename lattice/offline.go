@@ -0,0 +1,86 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"strconv"
+)
+
+var (
+	// ErrEnvelopeNotSigned 交易信封未签名
+	ErrEnvelopeNotSigned = errors.New("envelope is not signed")
+	// ErrInvalidSignature 交易的签名者不是Owner，交易在签名后被篡改，或签名无法在本地验证
+	ErrInvalidSignature = errors.New("invalid transaction signature")
+)
+
+func (svc *lattice) NewUnsignedEnvelope(ctx context.Context, chainId, accountAddress string, transaction *block.Transaction) (*block.Envelope, error) {
+	chainConfig, err := svc.GetChainConfig(ctx, chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 离线签名耗时较长，直接查询包括pending交易在内的最新区块，不使用区块缓存
+	cancelCtx, cancelFunc := context.WithTimeout(client.WithAccountRouting(ctx, chainId, accountAddress), svc.options.httpRequestTimeout())
+	defer cancelFunc()
+	latestBlock, err := svc.httpApi.GetLatestBlockWithPending(cancelCtx, chainId, accountAddress)
+	if err != nil {
+		svc.logger.Error("Failed to get the latest block", "获取账户的最新区块失败", logger.String("chainId", chainId), logger.String("accountAddress", accountAddress), logger.Err(err))
+		return nil, err
+	}
+
+	transaction.Owner = accountAddress
+	transaction.Height = latestBlock.Height + 1
+	transaction.ParentHash = latestBlock.Hash
+	transaction.DaemonHash = latestBlock.DaemonBlockHash
+	transaction.CodeHash, err = svc.computeCodeHash(chainConfig.Curve, transaction.Code)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Sign, transaction.Hash = "", ""
+	return block.NewEnvelope(chainConfig.id, chainConfig.Curve, transaction), nil
+}
+
+func (svc *lattice) BroadcastEnvelope(ctx context.Context, envelope *block.Envelope) (*common.Hash, error) {
+	if !envelope.IsSigned() {
+		return nil, ErrEnvelopeNotSigned
+	}
+	chainId := strconv.FormatUint(envelope.ChainId, 10)
+	transaction := envelope.Transaction
+	tx := &TxContext{ChainId: chainId, Owner: transaction.Owner, Transaction: transaction}
+	chainConfig, err := svc.GetChainConfig(ctx, chainId)
+	if err != nil {
+		return nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	if envelope.Curve != chainConfig.Curve {
+		return nil, svc.runTxErrorHooks(ctx, tx, fmt.Errorf("envelope curve %s does not match the chain curve %s", envelope.Curve, chainConfig.Curve))
+	}
+	ok, err := transaction.VerifySignature(envelope.ChainId, envelope.Curve)
+	if err != nil {
+		svc.logger.Error("Failed to verify the signature of the envelope", "验证交易信封的签名失败", logger.String("owner", transaction.Owner), logger.Err(err))
+		return nil, svc.runTxErrorHooks(ctx, tx, fmt.Errorf("%w: %w", ErrInvalidSignature, err))
+	}
+	if !ok {
+		return nil, svc.runTxErrorHooks(ctx, tx, ErrInvalidSignature)
+	}
+
+	ctx = client.WithAccountRouting(ctx, chainId, transaction.Owner)
+	if err := svc.runTxHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.BeforeSend }); err != nil {
+		svc.logger.Error("The transaction was aborted by a hook", "交易被钩子中止", logger.String("chainId", chainId), logger.String("owner", transaction.Owner), logger.Err(err))
+		return nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	cancelCtx, cancelFunc := context.WithTimeout(ctx, svc.options.httpRequestTimeout())
+	defer cancelFunc()
+	hash, err := svc.httpApi.SendSignedTransaction(cancelCtx, chainId, transaction)
+	if err != nil {
+		svc.logger.Error("Failed to broadcast the offline signed transaction", "广播离线签名的交易失败", logger.String("owner", transaction.Owner), logger.Err(err))
+		return nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	tx.Hash = hash
+	svc.runTxNotifyHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.AfterSend })
+	return hash, nil
+}
@@ -0,0 +1,316 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wylu1037/lattice-go/common/errs"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"net/http"
+	"time"
+)
+
+// Option New 的可选项
+type Option func(*latticeConfig)
+
+// New 初始化的配置
+type latticeConfig struct {
+	chainConfig          *ChainConfig
+	connectingNodeConfig *ConnectingNodeConfig
+	nodes                []*ConnectingNodeConfig
+	blockCache           BlockCache
+	accountLock          AccountLock
+	transport            http.RoundTripper
+	metricsRecorder      client.MetricsRecorder
+	tracer               client.Tracer
+	logger               logger.Logger
+	language             string
+	discoverChainIds     []string
+	baseOptions          *Options // WithOptions 设置的配置
+	options              Options
+}
+
+// WithChainConfig 设置默认的链配置，未注册配置的链使用该配置，未开启自动发现且未通过 WithChain 注册链时必须
+func WithChainConfig(chainConfig *ChainConfig) Option {
+	return func(config *latticeConfig) {
+		config.chainConfig = chainConfig
+	}
+}
+
+// WithChain 注册链的配置，可以多次使用注册主链和多条子链
+func WithChain(chainId string, chainConfig *ChainConfig) Option {
+	return func(config *latticeConfig) {
+		if config.options.Chains == nil {
+			config.options.Chains = make(map[string]*ChainConfig)
+		}
+		config.options.Chains[chainId] = chainConfig
+	}
+}
+
+// WithChainDiscovery 从节点自动发现链配置，初始化时查询指定的链ID，其它链ID在首次使用时查询，
+// 配置的曲线与节点不一致时返回 ErrCurveMismatch
+func WithChainDiscovery(chainIds ...string) Option {
+	return func(config *latticeConfig) {
+		config.options.DiscoverChainConfig = true
+		config.discoverChainIds = append(config.discoverChainIds, chainIds...)
+	}
+}
+
+// WithNode 设置连接的节点，必须
+func WithNode(node *ConnectingNodeConfig) Option {
+	return func(config *latticeConfig) {
+		config.connectingNodeConfig = node
+	}
+}
+
+// WithNodes 追加其它节点，http请求会按照 Options.RoutingPolicy 在所有节点间路由，并在网络错误时切换到其它节点
+func WithNodes(nodes ...*ConnectingNodeConfig) Option {
+	return func(config *latticeConfig) {
+		config.nodes = append(config.nodes, nodes...)
+	}
+}
+
+// WithOptions 设置其它可选配置，会复制一份，不会修改调用方的 Options。
+// 无论参数顺序如何，WithOptions 都先于其它选项生效，其它选项在其基础上修改，多次使用时以最后一次为准
+func WithOptions(options *Options) Option {
+	return func(config *latticeConfig) {
+		config.baseOptions = options
+	}
+}
+
+// 复制可选配置，options为nil时返回空的配置
+func cloneOptions(options *Options) Options {
+	if options == nil {
+		return Options{}
+	}
+	clone := *options
+	clone.TxHooks = append([]*TxHooks(nil), options.TxHooks...)
+	clone.HeightConflictCodes = append([]int16(nil), options.HeightConflictCodes...)
+	clone.Chains = make(map[string]*ChainConfig, len(options.Chains))
+	for chainId, chainConfig := range options.Chains {
+		clone.Chains[chainId] = chainConfig
+	}
+	return clone
+}
+
+// WithTransport 设置http请求的transport，优先于 Options.Transport，可用于注入代理、监控等中间件
+func WithTransport(transport http.RoundTripper) Option {
+	return func(config *latticeConfig) {
+		config.transport = transport
+	}
+}
+
+// WithInstrumentation 记录http请求的指标、区块缓存的命中率，并追踪http请求，
+// 可以使用 client.NewMetricsRegistry 在进程内收集指标
+func WithInstrumentation(recorder client.MetricsRecorder, tracer client.Tracer) Option {
+	return func(config *latticeConfig) {
+		config.metricsRecorder = recorder
+		config.tracer = tracer
+	}
+}
+
+// WithHeightConflictCodes 设置节点表示区块高度或父哈希与链上不一致的错误码，返回这些错误码时刷新区块并重新发送交易
+func WithHeightConflictCodes(codes ...int16) Option {
+	return func(config *latticeConfig) {
+		config.options.HeightConflictCodes = append(config.options.HeightConflictCodes, codes...)
+	}
+}
+
+// WithHttpTimeout 设置单次请求节点的超时时间，默认15s
+func WithHttpTimeout(timeout time.Duration) Option {
+	return func(config *latticeConfig) {
+		config.options.HttpRequestTimeout = timeout
+	}
+}
+
+// WithLogger 设置该实例的日志，包括其节点客户端和回执等待器，可以使用 logger.NewZerologLogger、logger.NewSlogLogger 适配或自定义实现。
+// 未设置时使用 logger.SetLogger 设置的进程级日志，默认输出到 zerolog/log 的全局日志
+func WithLogger(l logger.Logger) Option {
+	return func(config *latticeConfig) {
+		config.logger = l
+	}
+}
+
+// WithGlobalLanguage 设置错误信息和日志的语言，errs.LanguageEn 或 errs.LanguageZhHans，默认为中文。
+// 语言是进程级的设置，等同于调用 errs.SetLanguage，会影响进程内所有的Lattice
+func WithGlobalLanguage(language string) Option {
+	return func(config *latticeConfig) {
+		config.language = language
+	}
+}
+
+// WithRetryStrategy 设置默认的等待回执策略，等待回执时未指定策略则使用该策略
+func WithRetryStrategy(retryStrategy *RetryStrategy) Option {
+	return func(config *latticeConfig) {
+		config.options.RetryStrategy = retryStrategy
+	}
+}
+
+// WithTxHooks 追加交易生命周期的钩子，如 NewAmountLimitHook、NewLinkerWhitelistHook
+func WithTxHooks(hooks ...*TxHooks) Option {
+	return func(config *latticeConfig) {
+		config.options.TxHooks = append(config.options.TxHooks, hooks...)
+	}
+}
+
+// WithBlockCache 设置区块缓存，未设置时禁用缓存，多个进程共用账户时使用 NewRedisBlockCache
+func WithBlockCache(blockCache BlockCache) Option {
+	return func(config *latticeConfig) {
+		config.blockCache = blockCache
+	}
+}
+
+// WithAccountLock 设置账户锁，未设置时使用 NewAccountLock，多个进程共用账户时使用 NewRedisAccountLock
+func WithAccountLock(accountLock AccountLock) Option {
+	return func(config *latticeConfig) {
+		config.accountLock = accountLock
+	}
+}
+
+// New 初始化LatticeApi，配置无效时返回错误
+//
+// Parameters:
+//   - opts ...Option: 必须包含 WithNode，未开启 WithChainDiscovery 且未使用 WithChain 时必须包含 WithChainConfig
+//
+// Returns:
+//   - Lattice
+//   - error
+func New(opts ...Option) (Lattice, error) {
+	// 先找出 WithOptions 设置的配置，再在其基础上应用所有选项
+	base := new(latticeConfig)
+	for _, opt := range opts {
+		opt(base)
+	}
+	config := &latticeConfig{options: cloneOptions(base.baseOptions)}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	options := &config.options
+	if config.chainConfig == nil {
+		if !options.DiscoverChainConfig && len(options.Chains) == 0 {
+			return nil, errors.New("未指定ChainConfig")
+		}
+		// 未注册的链在使用时会因为缺少曲线返回错误
+		config.chainConfig = new(ChainConfig)
+	} else if err := config.chainConfig.validate(options.DiscoverChainConfig); err != nil {
+		return nil, err
+	}
+	if config.connectingNodeConfig == nil {
+		return nil, errors.New("未指定连接的节点")
+	}
+	if err := config.connectingNodeConfig.validate(); err != nil {
+		return nil, err
+	}
+	if options.HttpRequestTimeout < 0 {
+		return nil, fmt.Errorf("http请求的超时时间不能为负数：%s", options.HttpRequestTimeout)
+	}
+	allNodes := append(append([]*ConnectingNodeConfig{}, options.Nodes...), config.nodes...)
+	nodes := make([]*client.NodeEndpoint, 0, len(allNodes))
+	for _, node := range allNodes {
+		if node == nil {
+			return nil, errors.New("节点配置不能为nil")
+		}
+		if err := node.validate(); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &client.NodeEndpoint{HttpUrl: node.GetHttpUrl(), GinServerUrl: node.GetGinServerUrl()})
+	}
+	options.Nodes = allNodes
+	for _, hooks := range options.TxHooks {
+		if hooks == nil {
+			return nil, errors.New("交易钩子不能为nil")
+		}
+	}
+
+	if config.language != "" {
+		if config.language != errs.LanguageEn && config.language != errs.LanguageZhHans {
+			return nil, fmt.Errorf("不支持的语言: %s", config.language)
+		}
+		errs.SetLanguage(config.language)
+	}
+	log := logger.NewHelper(config.logger)
+
+	// 未指定transport时由Lattice创建，Close 时关闭其空闲连接
+	var ownedTransport *http.Transport
+	transport := config.transport
+	if transport == nil {
+		httpTransport := options.GetTransport()
+		if options.Transport == nil {
+			ownedTransport = httpTransport
+		}
+		transport = httpTransport
+	}
+	if config.metricsRecorder != nil || config.tracer != nil {
+		transport = client.NewInstrumentedTransport(transport, config.metricsRecorder, config.tracer)
+	}
+	connectingNodeConfig := config.connectingNodeConfig
+	httpApi, err := client.NewHttpApiWithError(&client.HttpApiInitParam{
+		HttpUrl:                    connectingNodeConfig.GetHttpUrl(),
+		GinServerUrl:               connectingNodeConfig.GetGinServerUrl(),
+		Transport:                  transport,
+		JwtSecret:                  connectingNodeConfig.JwtSecret,
+		JwtTokenExpirationDuration: connectingNodeConfig.JwtTokenExpirationDuration,
+		TokenProvider:              connectingNodeConfig.TokenProvider,
+		Nodes:                      nodes,
+		RoutingPolicy:              options.RoutingPolicy,
+		HealthCheckInterval:        options.HealthCheckInterval,
+		Logger:                     config.logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var websocketApi client.WebsocketApi
+	if connectingNodeConfig.WebsocketPort != 0 {
+		websocketApi = client.NewWebsocketApi(&client.WebsocketApiInitParam{
+			WebsocketUrl:               connectingNodeConfig.GetWebsocketUrl(),
+			JwtSecret:                  connectingNodeConfig.JwtSecret,
+			JwtTokenExpirationDuration: connectingNodeConfig.JwtTokenExpirationDuration,
+			TokenProvider:              connectingNodeConfig.TokenProvider,
+			Logger:                     config.logger,
+		})
+	}
+
+	blockCache := config.blockCache
+	if blockCache == nil {
+		blockCache = newDisabledMemoryBlockCache(httpApi)
+	}
+	if config.metricsRecorder != nil {
+		blockCache = NewInstrumentedBlockCache(blockCache, config.metricsRecorder)
+	}
+	blockCache.SetHttpApi(httpApi)
+	accountLock := config.accountLock
+	if accountLock == nil {
+		accountLock = NewAccountLock()
+	}
+
+	svc := &lattice{
+		receiptWaiter:        newReceiptWaiter(httpApi, websocketApi, options.ReceiptPollInterval, options.httpRequestTimeout(), log),
+		chainConfig:          config.chainConfig,
+		connectingNodeConfig: connectingNodeConfig,
+		options:              options,
+		transport:            ownedTransport,
+		httpApi:              httpApi,
+		websocketApi:         websocketApi,
+		blockCache:           blockCache,
+		accountLock:          accountLock,
+		chains:               make(map[string]*ChainConfig),
+		chainConfigs:         make(map[string]*ChainConfig),
+		logger:               log,
+	}
+	for chainId, chainConfig := range options.Chains {
+		if err := svc.RegisterChain(chainId, chainConfig); err != nil {
+			_ = svc.Close()
+			return nil, err
+		}
+	}
+	for _, chainId := range config.discoverChainIds {
+		if _, err := svc.GetChainConfig(context.Background(), chainId); err != nil {
+			_ = svc.Close()
+			return nil, err
+		}
+	}
+	return svc, nil
+}
@@ -0,0 +1,153 @@
+package lattice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 模拟节点的latc接口，回执始终未生成
+type closeTestLatcService struct{}
+
+func (s *closeTestLatcService) GetReceipt(_ string) (*types.Receipt, error) {
+	return nil, errors.New("receipt not found")
+}
+
+func (s *closeTestLatcService) NewDBlock(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	return notifier.CreateSubscription(), nil
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 13000}))
+	assert.Error(t, err)
+	_, err = New(WithChainConfig(&ChainConfig{}), WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 13000}))
+	assert.Error(t, err)
+	_, err = New(WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}))
+	assert.Error(t, err)
+	_, err = New(
+		WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}),
+		WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 13000}),
+		WithNodes(&ConnectingNodeConfig{Ip: "127.0.0.2"}),
+	)
+	assert.Error(t, err)
+
+	options := &Options{MaxIdleConnsPerHost: 10}
+	svc, err := New(
+		WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}),
+		WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 13000}),
+		WithOptions(options),
+		WithHttpTimeout(3*time.Second),
+		WithRetryStrategy(DefaultFixedRetryStrategy()),
+	)
+	assert.NoError(t, err)
+	assert.Nil(t, options.Transport)
+	assert.Zero(t, options.HttpRequestTimeout)
+	assert.Equal(t, 3*time.Second, svc.(*lattice).options.httpRequestTimeout())
+	assert.Equal(t, DefaultFixedRetryStrategy(), svc.(*lattice).options.RetryStrategy)
+
+	// WithOptions does not overwrite the options placed before it
+	hooks := &TxHooks{}
+	options = &Options{MaxIdleConnsPerHost: 10, HttpRequestTimeout: time.Second, TxHooks: []*TxHooks{{}}}
+	svc, err = New(
+		WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}),
+		WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 13000}),
+		WithHttpTimeout(3*time.Second),
+		WithTxHooks(hooks),
+		WithOptions(options),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, svc.(*lattice).options.httpRequestTimeout())
+	assert.Equal(t, 10, svc.(*lattice).options.MaxIdleConnsPerHost)
+	assert.Len(t, svc.(*lattice).options.TxHooks, 2)
+	assert.Same(t, hooks, svc.(*lattice).options.TxHooks[1])
+	assert.Len(t, options.TxHooks, 1)
+}
+
+func TestLattice_Close(t *testing.T) {
+	rpcServer := rpc.NewServer()
+	assert.NoError(t, rpcServer.RegisterName("latc", new(closeTestLatcService)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") == "websocket" {
+			rpcServer.WebsocketHandler([]string{"*"}).ServeHTTP(w, r)
+			return
+		}
+		rpcServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	serverUrl, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.ParseUint(serverUrl.Port(), 10, 16)
+	assert.NoError(t, err)
+	baseline := runtime.NumGoroutine()
+
+	node := &ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: uint16(port), WebsocketPort: uint16(port)}
+	svc, err := New(
+		WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}),
+		WithNode(node),
+		WithNodes(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: uint16(port)}),
+		WithOptions(&Options{ReceiptPollInterval: 10 * time.Millisecond}),
+	)
+	assert.NoError(t, err)
+
+	// 节点池的健康检查、回执轮询和websocket订阅都在运行
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.(*lattice).receiptWaiter.Wait(context.Background(), "1", common.HexToHash("0x01"))
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	assert.Greater(t, runtime.NumGoroutine(), baseline)
+
+	assert.NoError(t, svc.Close())
+	assert.ErrorIs(t, <-done, ErrReceiptWaiterClosed)
+	// assert.Eventually 在新的goroutine中执行条件，这里直接轮询
+	deadline := time.Now().Add(3 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline)
+}
+
+func TestNew_Logger(t *testing.T) {
+	defer logger.SetLogger(logger.GetLogger())
+	var global, first, second bytes.Buffer
+	logger.SetLogger(logger.NewZerologLogger(zerolog.New(&global)))
+	newLattice := func(buf *bytes.Buffer) Lattice {
+		svc, err := New(
+			WithChainConfig(&ChainConfig{Curve: crypto.Sm2p256v1}),
+			WithNode(&ConnectingNodeConfig{Ip: "127.0.0.1", HttpPort: 1}),
+			WithLogger(logger.NewZerologLogger(zerolog.New(buf))),
+		)
+		assert.NoError(t, err)
+		return svc
+	}
+	svc := newLattice(&first)
+	defer svc.Close()
+	other := newLattice(&second)
+	defer other.Close()
+
+	// both the lattice and its http client log to the logger of the instance
+	_, err := svc.GetChainConfig(context.Background(), "invalid")
+	assert.Error(t, err)
+	_, err = svc.(*lattice).httpApi.GetNodeVersion(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, first.String(), "链ID不合法")
+	assert.Contains(t, first.String(), "发送http请求失败")
+	assert.Empty(t, second.String())
+	assert.Empty(t, global.String())
+}
@@ -0,0 +1,560 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+)
+
+const defaultPipelineMaxInFlight = 16
+
+var (
+	// ErrPipelineClosed 流水线已关闭
+	ErrPipelineClosed = errors.New("transaction pipeline is closed")
+	// ErrPipelineRolledBack 前序交易发送失败，流水线已回滚，该交易未被发送
+	ErrPipelineRolledBack = errors.New("transaction pipeline rolled back by a previous failure")
+	// ErrLocalHashMismatch 节点返回的交易哈希与本地计算的哈希不一致，后续交易的父哈希无效
+	ErrLocalHashMismatch = errors.New("the hash returned by the node does not match the local hash")
+	// ErrAccountLeaseLost 账户锁的租约已丢失，其它进程可能正在使用该账户，流水线不再发送交易
+	ErrAccountLeaseLost = errors.New("the lease of the account lock was lost")
+
+	// 获取账户锁后流水线又变为空闲并释放了锁，需要重新获取
+	errPipelineNotLocked = errors.New("transaction pipeline does not hold the account lock")
+	// 本地哈希还未与节点核对，需要等待在途交易完成后再发送
+	errPipelineHashUnverified = errors.New("transaction pipeline has not verified the local hash")
+)
+
+// PipelineOptions 交易流水线的配置
+type PipelineOptions struct {
+	MaxInFlight int // 已签名但还未得到节点响应的交易的最大数量，达到上限时 Submit 会阻塞，默认16
+}
+
+// TransactionPipeline 单个账户的交易流水线，提交的交易在本地依次分配高度和父哈希并立即签名，
+// 再按提交顺序合并为批量请求发送到节点，一次网络往返可以发送多笔交易。
+//
+// 流水线有交易在途时持有账户锁，在途交易全部完成后将链头写入区块缓存并释放账户锁。
+// 某笔交易发送失败时，链头回滚到最后一笔成功的交易，其后节点未接受的交易全部以 ErrPipelineRolledBack 失败。
+// 批量请求失败时节点可能已经处理了部分交易，会先向节点查询每笔交易是否已被接受。
+// 后续交易的父哈希是本地计算的哈希，节点返回的哈希与本地一致之前，流水线每次只有一笔在途交易。
+// 账户锁实现了 FencingAccountLock 时，租约丢失后待发送的交易全部以 ErrAccountLeaseLost 失败。
+// 交易钩子只执行 BeforeSend、AfterSend、OnError，BeforeSend 按提交顺序在发送前执行，中止某笔交易时其后的交易全部回滚。
+type TransactionPipeline interface {
+	// Submit 提交一笔交易，交易的Height、ParentHash、DaemonHash由流水线设置
+	//
+	// Parameters:
+	//   - ctx context.Context: 作用于等待在途交易数量低于上限和等待账户锁
+	//   - transaction *block.Transaction: 未签名的交易，Owner为空时使用凭证的账户地址
+	//
+	// Returns:
+	//   - PendingTransaction
+	//   - error
+	Submit(ctx context.Context, transaction *block.Transaction) (PendingTransaction, error)
+
+	// Close 停止接收新的交易，并等待在途交易全部完成
+	Close()
+}
+
+// PendingTransaction 已提交到流水线的交易
+type PendingTransaction interface {
+	// LocalHash 本地计算的交易哈希
+	LocalHash() common.Hash
+
+	// Wait 等待节点的响应
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//
+	// Returns:
+	//   - *common.Hash: 节点返回的交易哈希
+	//   - error
+	Wait(ctx context.Context) (*common.Hash, error)
+}
+
+type pendingTransaction struct {
+	transaction *block.Transaction
+	tx          *TxContext
+	localHash   common.Hash
+	epoch       uint64 // 提交时流水线的纪元，回滚后纪元递增，旧纪元的交易不再发送
+	done        chan struct{}
+	hash        *common.Hash
+	err         error
+}
+
+func (p *pendingTransaction) LocalHash() common.Hash {
+	return p.localHash
+}
+
+func (p *pendingTransaction) Wait(ctx context.Context) (*common.Hash, error) {
+	select {
+	case <-p.done:
+		return p.hash, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pendingTransaction) resolve(hash *common.Hash, err error) {
+	p.hash, p.err = hash, err
+	close(p.done)
+}
+
+type transactionPipeline struct {
+	svc         *lattice
+	credentials *Credentials
+	chainId     string
+	chainIdInt  uint64
+	signer      crypto.Signer
+
+	slots     chan struct{}            // 在途交易的信号量
+	queue     chan *pendingTransaction // 待发送的交易，容量与信号量一致，发送不会阻塞
+	obtaining chan struct{}            // 同一时刻只有一个协程获取账户锁，不持有 mu
+	done      chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	inFlight  int
+	locked    bool               // 是否持有账户锁
+	leaseLost <-chan struct{}    // 账户锁的租约丢失信号，账户锁未实现 FencingAccountLock 时为nil
+	head      *types.LatestBlock // 已分配的链头，包括还未得到响应的交易
+	confirmed *types.LatestBlock // 节点已接受的链头
+	epoch     uint64
+	verified  bool          // 节点返回的哈希是否与本地计算的哈希一致，一致后才以本地哈希作为后续交易的父哈希
+	idle      chan struct{} // 在途交易全部完成时关闭，等待本地哈希核对的 Submit 使用
+}
+
+// NewTransactionPipeline 创建账户的交易流水线
+//
+// Parameters:
+//   - credentials *Credentials: 账户凭证
+//   - chainId string
+//   - options *PipelineOptions: 为nil时使用默认配置
+//
+// Returns:
+//   - TransactionPipeline
+//   - error
+func (svc *lattice) NewTransactionPipeline(credentials *Credentials, chainId string, options *PipelineOptions) (TransactionPipeline, error) {
+	chainConfig, err := svc.GetChainConfig(context.Background(), chainId)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := credentials.GetSigner(chainConfig.Curve)
+	if err != nil {
+		svc.logger.Error("Failed to get the signer", "获取账户的签名者失败", logger.String("accountAddress", credentials.AccountAddress), logger.Err(err))
+		return nil, err
+	}
+	maxInFlight := defaultPipelineMaxInFlight
+	if options != nil && options.MaxInFlight > 0 {
+		maxInFlight = options.MaxInFlight
+	}
+
+	pipeline := &transactionPipeline{
+		svc:         svc,
+		credentials: credentials,
+		chainId:     chainId,
+		chainIdInt:  chainConfig.id,
+		signer:      signer,
+		slots:       make(chan struct{}, maxInFlight),
+		queue:       make(chan *pendingTransaction, maxInFlight),
+		obtaining:   make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	go pipeline.run()
+	return pipeline, nil
+}
+
+func (p *transactionPipeline) Submit(ctx context.Context, transaction *block.Transaction) (PendingTransaction, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if transaction.Owner == "" {
+		transaction.Owner = p.credentials.AccountAddress
+	}
+	tx := &TxContext{ChainId: p.chainId, Owner: transaction.Owner, Transaction: transaction}
+	for {
+		if err := p.obtain(ctx); err != nil {
+			<-p.slots
+			return nil, p.svc.runTxErrorHooks(ctx, tx, err)
+		}
+		pending, err := p.enqueue(tx)
+		if errors.Is(err, errPipelineNotLocked) {
+			continue
+		}
+		if errors.Is(err, errPipelineHashUnverified) {
+			select {
+			case <-p.idleSignal():
+				continue
+			case <-ctx.Done():
+				<-p.slots
+				return nil, p.svc.runTxErrorHooks(ctx, tx, ctx.Err())
+			}
+		}
+		if err != nil {
+			<-p.slots
+			return nil, p.svc.runTxErrorHooks(ctx, tx, err)
+		}
+		return pending, nil
+	}
+}
+
+// 流水线未持有账户锁时获取账户锁并加载链头，等待账户锁时不持有 p.mu，以免阻塞在途交易的完成
+func (p *transactionPipeline) obtain(ctx context.Context) error {
+	select {
+	case p.obtaining <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.obtaining }()
+
+	p.mu.Lock()
+	closed, locked := p.closed, p.locked
+	p.mu.Unlock()
+	if closed {
+		return ErrPipelineClosed
+	}
+	if locked {
+		return nil
+	}
+
+	address := p.credentials.AccountAddress
+	if err := obtainAccountLock(ctx, p.svc.accountLock, p.chainId, address); err != nil {
+		p.svc.logger.Error("Failed to obtain the account lock", "获取账户锁失败", logger.String("accountAddress", address), logger.Err(err))
+		return err
+	}
+	latestBlock, err := p.svc.blockCache.GetBlock(p.chainId, address)
+	if err != nil {
+		p.svc.accountLock.Unlock(p.chainId, address)
+		p.svc.logger.Error("Failed to get the latest block", "获取账户的最新区块失败", logger.String("accountAddress", address), logger.Err(err))
+		return err
+	}
+	var leaseLost <-chan struct{}
+	if fencingLock, ok := p.svc.accountLock.(FencingAccountLock); ok {
+		leaseLost, _ = fencingLock.LeaseLost(p.chainId, address)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.svc.accountLock.Unlock(p.chainId, address)
+		return ErrPipelineClosed
+	}
+	p.locked, p.leaseLost = true, leaseLost
+	p.head, p.confirmed = copyLatestBlock(latestBlock), copyLatestBlock(latestBlock)
+	return nil
+}
+
+func (p *transactionPipeline) enqueue(tx *TxContext) (*pendingTransaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPipelineClosed
+	}
+	if !p.locked {
+		return nil, errPipelineNotLocked
+	}
+	if !p.verified && p.inFlight > 0 {
+		return nil, errPipelineHashUnverified
+	}
+
+	transaction := tx.Transaction
+	transaction.Height = p.head.Height + 1
+	transaction.ParentHash = p.head.Hash
+	transaction.DaemonHash = p.head.DaemonBlockHash
+	if err := transaction.SignTXWithSigner(p.chainIdInt, p.signer); err != nil {
+		p.svc.logger.Error("Failed to sign the transaction", "签名交易失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Err(err))
+		p.releaseIfIdle()
+		return nil, err
+	}
+	localHash, err := transaction.BlockHash(p.signer.Curve())
+	if err != nil {
+		p.svc.logger.Error("Failed to compute the transaction hash", "计算交易哈希失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Err(err))
+		p.releaseIfIdle()
+		return nil, err
+	}
+
+	p.head.Height, p.head.Hash = transaction.Height, localHash
+	p.inFlight++
+	pending := &pendingTransaction{transaction: transaction, tx: tx, localHash: localHash, epoch: p.epoch, done: make(chan struct{})}
+	p.queue <- pending
+	return pending, nil
+}
+
+// 在途交易全部完成时关闭的信号
+func (p *transactionPipeline) idleSignal() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	if p.idle == nil {
+		p.idle = make(chan struct{})
+	}
+	return p.idle
+}
+
+func (p *transactionPipeline) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.done
+		return
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+	<-p.done
+}
+
+// 按提交顺序取出所有待发送的交易合并为一个批量请求
+func (p *transactionPipeline) run() {
+	defer close(p.done)
+	for first := range p.queue {
+		batch := []*pendingTransaction{first}
+	drain:
+		for {
+			select {
+			case pending, ok := <-p.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, pending)
+			default:
+				break drain
+			}
+		}
+		p.send(batch)
+	}
+}
+
+func (p *transactionPipeline) send(batch []*pendingTransaction) {
+	p.mu.Lock()
+	epoch := p.epoch
+	p.mu.Unlock()
+
+	// 回滚前签名的交易不再发送
+	sendable := make([]*pendingTransaction, 0, len(batch))
+	for _, pending := range batch {
+		if pending.epoch != epoch {
+			p.complete(pending, nil, ErrPipelineRolledBack)
+		} else {
+			sendable = append(sendable, pending)
+		}
+	}
+	if len(sendable) == 0 {
+		return
+	}
+
+	if p.isLeaseLost() {
+		p.svc.logger.Error("The lease of the account lock was lost, the pipeline stops sending", "账户锁的租约已丢失，流水线停止发送交易", logger.String("accountAddress", p.credentials.AccountAddress))
+		p.rollback(ErrAccountLeaseLost, false)
+		for _, pending := range sendable {
+			p.complete(pending, nil, ErrAccountLeaseLost)
+		}
+		return
+	}
+
+	// 按顺序执行 BeforeSend，被中止的交易及其后的交易都不发送
+	routingCtx := client.WithAccountRouting(context.Background(), p.chainId, p.credentials.AccountAddress)
+	aborted, abortedAt := error(nil), len(sendable)
+	for i, pending := range sendable {
+		if err := p.svc.runTxHooks(routingCtx, pending.tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.BeforeSend }); err != nil {
+			p.svc.logger.Error("The transaction was aborted by a hook", "交易被钩子中止", logger.String("accountAddress", p.credentials.AccountAddress), logger.Uint64("height", pending.transaction.Height), logger.Err(err))
+			aborted, abortedAt = err, i
+			break
+		}
+	}
+
+	hashes := make([]*common.Hash, len(sendable))
+	var err error
+	var batchErr *client.BatchError
+	refresh := false
+	if abortedAt > 0 {
+		transactions := make([]*block.Transaction, abortedAt)
+		for i, pending := range sendable[:abortedAt] {
+			transactions[i] = pending.transaction
+		}
+		ctx, cancel := context.WithTimeout(routingCtx, p.svc.options.httpRequestTimeout())
+		var sent []*common.Hash
+		sent, err = p.svc.httpApi.SendSignedTransactions(ctx, p.chainId, transactions)
+		cancel()
+		if err != nil && !errors.As(err, &batchErr) {
+			// 请求失败时节点可能已经处理了部分交易，逐笔向节点确认
+			sent = p.lookup(sendable[:abortedAt])
+			// 本地哈希未经核对时查询不到不代表节点未接受，回滚时从节点刷新链头
+			refresh = !p.isVerified()
+		}
+		copy(hashes, sent)
+	}
+
+	// 节点不保证按顺序处理批量请求中的交易，以每笔交易的结果为准
+	var failure error
+	failures := make([]error, len(sendable))
+	for i, pending := range sendable {
+		hash := hashes[i]
+		switch {
+		case hash != nil && failure == nil:
+			p.confirm(pending, *hash)
+			if *hash != pending.localHash {
+				// 交易已被节点接受，但后续交易的父哈希无效
+				failure = ErrLocalHashMismatch
+				p.svc.logger.Error("The transaction hash returned by the node differs from the local one", "节点返回的交易哈希与本地计算的哈希不一致", logger.String("accountAddress", p.credentials.AccountAddress), logger.Stringer("hash", hash), logger.Stringer("localHash", pending.localHash))
+			}
+		case hash != nil:
+			// 前序交易失败但节点仍接受了该交易，回滚时从节点刷新链头
+			refresh = true
+			p.svc.logger.Warn("The node accepted a transaction after a failed one", "前序交易失败后节点仍接受了该交易", logger.String("accountAddress", p.credentials.AccountAddress), logger.Uint64("height", pending.transaction.Height), logger.Stringer("hash", hash))
+		case failure == nil:
+			failure = err
+			if i >= abortedAt {
+				failure = aborted
+			} else if batchErr != nil && batchErr.Errors[i] != nil {
+				failure = batchErr.Errors[i]
+			}
+			failures[i] = failure
+			p.svc.logger.Error("The pipeline failed to send the transaction", "流水线发送交易失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Uint64("height", pending.transaction.Height), logger.Err(failure))
+		default:
+			failures[i] = fmt.Errorf("%w: %w", ErrPipelineRolledBack, failure)
+		}
+	}
+	if failure != nil {
+		p.rollback(failure, refresh)
+	}
+	for i, pending := range sendable {
+		if failures[i] != nil {
+			p.complete(pending, nil, failures[i])
+		} else {
+			p.complete(pending, hashes[i], nil)
+		}
+	}
+}
+
+// 批量请求失败后按本地哈希向节点查询交易，返回与 sendable 一一对应的哈希，节点上不存在的交易为nil
+func (p *transactionPipeline) lookup(sendable []*pendingTransaction) []*common.Hash {
+	hashes := make([]*common.Hash, len(sendable))
+	localHashes := make([]string, len(sendable))
+	for i, pending := range sendable {
+		localHashes[i] = pending.localHash.String()
+	}
+	ctx, cancel := context.WithTimeout(client.WithAccountRouting(context.Background(), p.chainId, p.credentials.AccountAddress), p.svc.options.httpRequestTimeout())
+	defer cancel()
+	transactionBlocks, err := p.svc.httpApi.GetTransactionBlocksByHash(ctx, p.chainId, localHashes)
+	var batchErr *client.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		p.svc.logger.Error("Failed to look up the transactions of the failed batch", "查询发送失败的交易失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Err(err))
+		return hashes
+	}
+	for i, transactionBlock := range transactionBlocks {
+		if transactionBlock != nil {
+			hash := sendable[i].localHash
+			hashes[i] = &hash
+		}
+	}
+	return hashes
+}
+
+func (p *transactionPipeline) isLeaseLost() bool {
+	p.mu.Lock()
+	leaseLost := p.leaseLost
+	p.mu.Unlock()
+	select {
+	case <-leaseLost:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *transactionPipeline) isVerified() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.verified
+}
+
+func (p *transactionPipeline) confirm(pending *pendingTransaction, hash common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.confirmed.Height, p.confirmed.Hash = pending.transaction.Height, hash
+	if hash == pending.localHash {
+		p.verified = true
+	}
+}
+
+// 链头回滚到节点已接受的最后一笔交易，高度冲突或节点的链头不确定时从节点刷新链头，
+// 刷新时不持有 p.mu，刷新期间又提交了交易或再次回滚时放弃刷新的结果
+func (p *transactionPipeline) rollback(cause error, refresh bool) {
+	p.mu.Lock()
+	p.epoch++
+	epoch := p.epoch
+	p.head = copyLatestBlock(p.confirmed)
+	height := p.head.Height
+	p.mu.Unlock()
+	if !refresh && !p.svc.options.isHeightConflict(cause) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(client.WithAccountRouting(context.Background(), p.chainId, p.credentials.AccountAddress), p.svc.options.httpRequestTimeout())
+	defer cancel()
+	latestBlock, err := p.svc.httpApi.GetLatestBlockWithPending(ctx, p.chainId, p.credentials.AccountAddress)
+	if err != nil {
+		p.svc.logger.Error("Failed to refresh the latest block of the account", "刷新账户的最新区块失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Err(err))
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.epoch != epoch || !p.locked || p.head.Height != height {
+		p.svc.logger.Warn("The pipeline changed while refreshing the latest block, discarding the result", "刷新最新区块期间流水线发生了变化，放弃刷新的结果", logger.String("accountAddress", p.credentials.AccountAddress))
+		return
+	}
+	p.head, p.confirmed = copyLatestBlock(latestBlock), copyLatestBlock(latestBlock)
+}
+
+// 交易完成，执行 AfterSend 或 OnError 钩子，在途交易全部完成时将链头写入缓存并释放账户锁
+func (p *transactionPipeline) complete(pending *pendingTransaction, hash *common.Hash, err error) {
+	ctx := client.WithAccountRouting(context.Background(), p.chainId, p.credentials.AccountAddress)
+	if err != nil {
+		p.svc.runTxErrorHooks(ctx, pending.tx, err)
+	} else {
+		pending.tx.Hash = hash
+		p.svc.runTxNotifyHooks(ctx, pending.tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.AfterSend })
+	}
+	pending.resolve(hash, err)
+	p.mu.Lock()
+	p.inFlight--
+	p.releaseIfIdle()
+	p.mu.Unlock()
+	<-p.slots
+}
+
+// 调用时需持有 p.mu
+func (p *transactionPipeline) releaseIfIdle() {
+	if p.inFlight > 0 || !p.locked {
+		return
+	}
+	if err := p.svc.blockCache.SetBlock(p.chainId, p.credentials.AccountAddress, copyLatestBlock(p.confirmed)); err != nil {
+		p.svc.logger.Error("Failed to update the block cache", "更新区块缓存失败", logger.String("accountAddress", p.credentials.AccountAddress), logger.Err(err))
+	}
+	p.locked, p.leaseLost = false, nil
+	p.head, p.confirmed = nil, nil
+	if p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+	p.svc.accountLock.Unlock(p.chainId, p.credentials.AccountAddress)
+}
+
+func copyLatestBlock(latestBlock *types.LatestBlock) *types.LatestBlock {
+	copied := *latestBlock
+	return &copied
+}
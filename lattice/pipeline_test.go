@@ -0,0 +1,324 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/block"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 模拟节点，按顺序校验交易的高度和父哈希，rejectHeight 高度的交易会被拒绝一次，conflictHeight 高度的交易会以高度冲突被拒绝一次
+type pipelineHttpApi struct {
+	client.HttpApi
+	mu             sync.Mutex
+	head           types.LatestBlock
+	batches        int
+	rejectHeight   uint64
+	conflictHeight uint64
+	gate           chan struct{} // 不为nil时，批量请求等待关闭后才处理
+	dropResponse   bool          // 为true时处理交易后模拟响应丢失一次
+	foreignHash    bool          // 为true时节点计算的哈希与本地不同
+	refreshing     chan struct{} // 不为nil时，刷新链头开始时关闭
+	refreshGate    chan struct{} // 不为nil时，刷新链头等待关闭后才返回
+	accepted       map[common.Hash]bool
+}
+
+func (api *pipelineHttpApi) GetTransactionBlocksByHash(_ context.Context, _ string, hashes []string) ([]*types.TransactionBlock, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	transactionBlocks := make([]*types.TransactionBlock, len(hashes))
+	for i, hash := range hashes {
+		if api.accepted[common.HexToHash(hash)] {
+			transactionBlocks[i] = &types.TransactionBlock{Hash: common.HexToHash(hash)}
+		}
+	}
+	return transactionBlocks, nil
+}
+
+func (api *pipelineHttpApi) GetLatestBlock(context.Context, string, string) (*types.LatestBlock, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	head := api.head
+	return &head, nil
+}
+
+func (api *pipelineHttpApi) GetLatestBlockWithPending(context.Context, string, string) (*types.LatestBlock, error) {
+	if api.refreshing != nil {
+		close(api.refreshing)
+	}
+	if api.refreshGate != nil {
+		<-api.refreshGate
+	}
+	return api.GetLatestBlock(context.Background(), "", "")
+}
+
+func (api *pipelineHttpApi) SendSignedTransactions(_ context.Context, _ string, transactions []*block.Transaction) ([]*common.Hash, error) {
+	if api.gate != nil {
+		<-api.gate
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.batches++
+	hashes := make([]*common.Hash, len(transactions))
+	errs := make([]error, len(transactions))
+	for i, transaction := range transactions {
+		if transaction.Height == api.rejectHeight {
+			api.rejectHeight = 0
+			errs[i] = (&client.JsonRpcError{Code: -32000, Message: "insufficient balance"}).Error()
+			continue
+		}
+		if transaction.Height == api.conflictHeight {
+			api.conflictHeight = 0
+			errs[i] = (&client.JsonRpcError{Code: -32000, Message: "invalid height"}).Error()
+			continue
+		}
+		if transaction.Height != api.head.Height+1 || transaction.ParentHash != api.head.Hash {
+			errs[i] = (&client.JsonRpcError{Code: -32000, Message: "invalid height"}).Error()
+			continue
+		}
+		hash, err := transaction.BlockHash(crypto.Sm2p256v1)
+		if err != nil {
+			return nil, err
+		}
+		if api.foreignHash {
+			hash[0] ^= 0xff
+		}
+		api.head.Height, api.head.Hash = transaction.Height, hash
+		hashes[i] = &hash
+		if api.accepted == nil {
+			api.accepted = make(map[common.Hash]bool)
+		}
+		api.accepted[hash] = true
+	}
+	if api.dropResponse {
+		api.dropResponse = false
+		return nil, errors.New("connection reset by peer")
+	}
+	for _, err := range errs {
+		if err != nil {
+			return hashes, &client.BatchError{Errors: errs}
+		}
+	}
+	return hashes, nil
+}
+
+func newPipelineTestLattice(httpApi client.HttpApi) *lattice {
+	blockCache := NewMemoryBlockCache(10*time.Second, time.Minute, time.Minute)
+	blockCache.SetHttpApi(httpApi)
+	return &lattice{
+		httpApi:     httpApi,
+		chainConfig: &ChainConfig{Curve: crypto.Sm2p256v1},
+		blockCache:  blockCache,
+		accountLock: NewAccountLock(),
+		options:     &Options{},
+	}
+}
+
+// 先发送一笔交易，使流水线核对本地哈希
+func verifyPipelineHash(t *testing.T, pipeline TransactionPipeline) {
+	pending, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+	hash, err := pending.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, pending.LocalHash(), *hash)
+}
+
+func newPipelineTestTransaction() *block.Transaction {
+	return block.NewTransactionBuilder(block.TransactionTypeSend).
+		SetLinker(credentials.AccountAddress).
+		SetPayload(constant.ZeroPayload).
+		Build()
+}
+
+func TestTransactionPipeline_Submit(t *testing.T) {
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 10, Hash: common.HexToHash("0x0a")}}
+	svc := newPipelineTestLattice(httpApi)
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, &PipelineOptions{MaxInFlight: 8})
+	assert.NoError(t, err)
+
+	pendings := make([]PendingTransaction, 20)
+	for i := range pendings {
+		pendings[i], err = pipeline.Submit(context.Background(), newPipelineTestTransaction())
+		assert.NoError(t, err)
+	}
+	for _, pending := range pendings {
+		hash, err := pending.Wait(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, pending.LocalHash(), *hash)
+	}
+	pipeline.Close()
+
+	assert.Equal(t, uint64(30), httpApi.head.Height)
+	assert.Less(t, httpApi.batches, len(pendings))
+	cached, err := svc.blockCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, httpApi.head.Hash, cached.Hash)
+
+	_, err = pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.ErrorIs(t, err, ErrPipelineClosed)
+}
+
+func TestTransactionPipeline_Rollback(t *testing.T) {
+	// the first batch is held until all transactions are submitted, the rest are queued into the second batch
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}, rejectHeight: 4}
+	svc := newPipelineTestLattice(httpApi)
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+	verifyPipelineHash(t, pipeline)
+	httpApi.gate = make(chan struct{})
+
+	pendings := make([]PendingTransaction, 5)
+	for i := range pendings {
+		pendings[i], err = pipeline.Submit(context.Background(), newPipelineTestTransaction())
+		assert.NoError(t, err)
+	}
+	close(httpApi.gate)
+
+	for i, pending := range pendings {
+		_, err := pending.Wait(context.Background())
+		switch {
+		case i < 2:
+			assert.NoError(t, err)
+		case i == 2:
+			assert.Error(t, err)
+			assert.False(t, errors.Is(err, ErrPipelineRolledBack))
+		default:
+			assert.ErrorIs(t, err, ErrPipelineRolledBack)
+		}
+	}
+
+	// the chain head was rolled back to height 3, the next transaction continues from there
+	pending, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+	_, err = pending.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), httpApi.head.Height)
+}
+
+func TestTransactionPipeline_LostResponse(t *testing.T) {
+	// the node accepts the batch but the response is lost, the pipeline confirms each transaction with the node
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}}
+	svc := newPipelineTestLattice(httpApi)
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+	verifyPipelineHash(t, pipeline)
+	httpApi.dropResponse, httpApi.gate = true, make(chan struct{})
+
+	pendings := make([]PendingTransaction, 3)
+	for i := range pendings {
+		pendings[i], err = pipeline.Submit(context.Background(), newPipelineTestTransaction())
+		assert.NoError(t, err)
+	}
+	close(httpApi.gate)
+	for _, pending := range pendings {
+		hash, err := pending.Wait(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, pending.LocalHash(), *hash)
+	}
+}
+
+func TestTransactionPipeline_UnverifiedHash(t *testing.T) {
+	// the node hashes differently, every transaction waits for the previous one and chains on the hash returned by the node
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}, foreignHash: true}
+	svc := newPipelineTestLattice(httpApi)
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+
+	first, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+	// the second transaction is not enqueued while the first one is in flight
+	second, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+
+	hash, err := first.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.LocalHash(), *hash)
+	hash, err = second.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, second.LocalHash(), *hash)
+	assert.Equal(t, uint64(2), httpApi.head.Height)
+	assert.Equal(t, 2, httpApi.batches)
+}
+
+func TestTransactionPipeline_RefreshUnlocked(t *testing.T) {
+	// refreshing the chain head after a height conflict does not block submitting
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}, conflictHeight: 2}
+	svc := newPipelineTestLattice(httpApi)
+	svc.options = &Options{HeightConflictCodes: []int16{-32000}}
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+	verifyPipelineHash(t, pipeline)
+	httpApi.refreshing, httpApi.refreshGate = make(chan struct{}), make(chan struct{})
+
+	conflicted, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+	<-httpApi.refreshing
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pending, err := pipeline.Submit(ctx, newPipelineTestTransaction())
+	assert.NoError(t, err)
+	close(httpApi.refreshGate)
+
+	_, err = conflicted.Wait(context.Background())
+	var rpcErr *client.RpcError
+	assert.ErrorAs(t, err, &rpcErr)
+	// the refreshed head is discarded because a transaction was submitted meanwhile, it continues from the confirmed head
+	_, err = pending.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), httpApi.head.Height)
+}
+
+type leaseLostAccountLock struct {
+	AccountLock
+	lost chan struct{}
+}
+
+func (l *leaseLostAccountLock) ObtainContext(ctx context.Context, chainId, address string) error {
+	return obtainAccountLock(ctx, l.AccountLock, chainId, address)
+}
+
+func (l *leaseLostAccountLock) FencingToken(string, string) (int64, bool) {
+	return 1, true
+}
+
+func (l *leaseLostAccountLock) LeaseLost(string, string) (<-chan struct{}, bool) {
+	return l.lost, true
+}
+
+func TestTransactionPipeline_AccountLock(t *testing.T) {
+	httpApi := &pipelineHttpApi{head: types.LatestBlock{Height: 0}}
+	svc := newPipelineTestLattice(httpApi)
+	accountLock := &leaseLostAccountLock{AccountLock: NewAccountLock(), lost: make(chan struct{})}
+	svc.accountLock = accountLock
+	pipeline, err := svc.NewTransactionPipeline(credentials, chainId, nil)
+	assert.NoError(t, err)
+	defer pipeline.Close()
+
+	// the account lock is held by another sender, waiting for it respects the ctx
+	accountLock.Obtain(chainId, credentials.AccountAddress)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = pipeline.Submit(ctx, newPipelineTestTransaction())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	accountLock.Unlock(chainId, credentials.AccountAddress)
+
+	// nothing is sent once the lease is lost
+	close(accountLock.lost)
+	pending, err := pipeline.Submit(context.Background(), newPipelineTestTransaction())
+	assert.NoError(t, err)
+	_, err = pending.Wait(context.Background())
+	assert.ErrorIs(t, err, ErrAccountLeaseLost)
+	assert.Zero(t, httpApi.batches)
+}
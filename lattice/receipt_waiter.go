@@ -0,0 +1,258 @@
+package lattice
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReceiptPollInterval = 200 * time.Millisecond
+	// 订阅了守护区块时，轮询仅作为兜底，间隔放大的倍数
+	subscribedPollIntervalMultiple = 10
+)
+
+// ErrReceiptWaiterClosed 回执等待器已关闭
+var ErrReceiptWaiterClosed = errors.New("receipt waiter is closed")
+
+// NewReceiptWaiter 初始化回执等待器，同一条链上所有等待中的交易共用一个轮询器
+//
+// Parameters:
+//   - httpApi client.HttpApi
+//   - websocketApi client.WebsocketApi: 不为nil时，每产生一个新的守护区块就查询一次回执，轮询仅作为兜底
+//   - pollInterval time.Duration: 轮询回执的间隔，<=0时使用默认值200ms
+//   - requestTimeout time.Duration: 单次批量查询回执的超时时间，<=0时使用默认值15s
+//
+// Returns:
+//   - ReceiptWaiter
+func NewReceiptWaiter(httpApi client.HttpApi, websocketApi client.WebsocketApi, pollInterval, requestTimeout time.Duration) ReceiptWaiter {
+	return newReceiptWaiter(httpApi, websocketApi, pollInterval, requestTimeout, nil)
+}
+
+// 创建输出到指定日志的回执等待器，log为nil时使用进程级日志
+func newReceiptWaiter(httpApi client.HttpApi, websocketApi client.WebsocketApi, pollInterval, requestTimeout time.Duration, log *logger.Helper) ReceiptWaiter {
+	if pollInterval <= 0 {
+		pollInterval = defaultReceiptPollInterval
+	}
+	if requestTimeout <= 0 {
+		requestTimeout = defaultHttpRequestTimeout
+	}
+	return &receiptWaiter{
+		httpApi:        httpApi,
+		websocketApi:   websocketApi,
+		pollInterval:   pollInterval,
+		requestTimeout: requestTimeout,
+		pollers:        make(map[string]*receiptPoller),
+		closeCh:        make(chan struct{}),
+		logger:         log,
+	}
+}
+
+type ReceiptWaiter interface {
+	// Wait 等待交易的回执，直到回执上链(守护区块高度不为0)或ctx结束
+	//
+	// Parameters:
+	//   - ctx context.Context
+	//   - chainId string
+	//   - hash common.Hash: 交易哈希
+	//
+	// Returns:
+	//   - *types.Receipt
+	//   - error
+	Wait(ctx context.Context, chainId string, hash common.Hash) (*types.Receipt, error)
+
+	// Close 关闭等待器，所有等待中的调用返回 ErrReceiptWaiterClosed
+	Close()
+}
+
+type receiptWaiter struct {
+	httpApi        client.HttpApi
+	websocketApi   client.WebsocketApi
+	pollInterval   time.Duration
+	requestTimeout time.Duration
+	logger         *logger.Helper
+
+	mu      sync.Mutex
+	pollers map[string]*receiptPoller // 每条链一个轮询器，没有等待中的交易时退出
+	closed  bool
+	closeCh chan struct{}
+}
+
+// receiptPoller 单条链上的回执轮询器，pending 由 receiptWaiter.mu 保护
+type receiptPoller struct {
+	waiter  *receiptWaiter
+	chainId string
+	pending map[common.Hash][]chan *types.Receipt
+}
+
+func (w *receiptWaiter) Wait(ctx context.Context, chainId string, hash common.Hash) (*types.Receipt, error) {
+	ch := make(chan *types.Receipt, 1)
+	if err := w.register(chainId, hash, ch); err != nil {
+		return nil, err
+	}
+
+	select {
+	case receipt, ok := <-ch:
+		if !ok {
+			return nil, ErrReceiptWaiterClosed
+		}
+		return receipt, nil
+	case <-ctx.Done():
+		w.deregister(chainId, hash, ch)
+		return nil, ctx.Err()
+	}
+}
+
+func (w *receiptWaiter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.closeCh)
+	for chainId, poller := range w.pollers {
+		for _, chs := range poller.pending {
+			for _, ch := range chs {
+				close(ch)
+			}
+		}
+		poller.pending = nil
+		delete(w.pollers, chainId)
+	}
+}
+
+func (w *receiptWaiter) register(chainId string, hash common.Hash, ch chan *types.Receipt) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrReceiptWaiterClosed
+	}
+	poller, ok := w.pollers[chainId]
+	if !ok {
+		poller = &receiptPoller{
+			waiter:  w,
+			chainId: chainId,
+			pending: make(map[common.Hash][]chan *types.Receipt),
+		}
+		w.pollers[chainId] = poller
+		go poller.run()
+	}
+	poller.pending[hash] = append(poller.pending[hash], ch)
+	return nil
+}
+
+func (w *receiptWaiter) deregister(chainId string, hash common.Hash, ch chan *types.Receipt) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	poller, ok := w.pollers[chainId]
+	if !ok {
+		return
+	}
+	chs := poller.pending[hash]
+	for i, c := range chs {
+		if c == ch {
+			chs = append(chs[:i], chs[i+1:]...)
+			break
+		}
+	}
+	if len(chs) == 0 {
+		delete(poller.pending, hash)
+	} else {
+		poller.pending[hash] = chs
+	}
+}
+
+// 唤醒所有等待该交易回执的调用
+func (w *receiptWaiter) resolve(poller *receiptPoller, receipt *types.Receipt) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range poller.pending[receipt.TBlockHash] {
+		ch <- receipt
+	}
+	delete(poller.pending, receipt.TBlockHash)
+}
+
+// 获取等待中的交易哈希，没有等待中的交易时注销轮询器并返回nil
+func (w *receiptWaiter) pendingHashes(poller *receiptPoller) []common.Hash {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(poller.pending) == 0 {
+		if w.pollers[poller.chainId] == poller {
+			delete(w.pollers, poller.chainId)
+		}
+		return nil
+	}
+	hashes := make([]common.Hash, 0, len(poller.pending))
+	for hash := range poller.pending {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (p *receiptPoller) run() {
+	interval := p.waiter.pollInterval
+	var daemonBlocks chan *types.DaemonBlock
+	if p.waiter.websocketApi != nil {
+		daemonBlocks = make(chan *types.DaemonBlock, 16)
+		sub, err := p.waiter.websocketApi.SubscribeNewDaemonBlock(context.Background(), p.chainId, daemonBlocks)
+		if err != nil {
+			p.waiter.logger.Warn("Failed to subscribe to daemon blocks, the receipt waiter falls back to polling", "订阅守护区块失败，回执等待器退化为轮询", logger.String("chainId", p.chainId), logger.Err(err))
+			daemonBlocks = nil
+		} else {
+			defer sub.Unsubscribe()
+			interval *= subscribedPollIntervalMultiple
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.waiter.closeCh:
+			return
+		case <-ticker.C:
+		case daemonBlock := <-daemonBlocks:
+			for _, receipt := range daemonBlock.Receipts {
+				if receipt != nil && receipt.DBlockNumber != 0 {
+					p.waiter.resolve(p, receipt)
+				}
+			}
+		}
+
+		hashes := p.waiter.pendingHashes(p)
+		if hashes == nil {
+			return
+		}
+		p.poll(hashes)
+	}
+}
+
+func (p *receiptPoller) poll(hashes []common.Hash) {
+	hexHashes := make([]string, len(hashes))
+	for i, hash := range hashes {
+		hexHashes[i] = hash.String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.waiter.requestTimeout)
+	defer cancel()
+	// 回执尚未生成时节点会返回错误，只处理查询成功的回执
+	receipts, err := p.waiter.httpApi.GetReceipts(ctx, p.chainId, hexHashes)
+	var batchErr *client.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		p.waiter.logger.Debug("Failed to get receipts in batch", "批量查询回执失败", logger.String("chainId", p.chainId), logger.Err(err))
+		return
+	}
+	for i, receipt := range receipts {
+		if receipt == nil || receipt.DBlockNumber == 0 {
+			continue
+		}
+		receipt.TBlockHash = hashes[i]
+		p.waiter.resolve(p, receipt)
+	}
+}
@@ -0,0 +1,119 @@
+package lattice
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type receiptHttpApi struct {
+	client.HttpApi
+	calls    atomic.Int32
+	mu       sync.Mutex
+	receipts map[string]*types.Receipt
+}
+
+func (api *receiptHttpApi) GetReceipts(_ context.Context, _ string, hashes []string) ([]*types.Receipt, error) {
+	api.calls.Add(1)
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	receipts := make([]*types.Receipt, len(hashes))
+	for i, hash := range hashes {
+		if receipt, ok := api.receipts[hash]; ok {
+			receipts[i] = receipt
+		} else {
+			receipts[i] = &types.Receipt{}
+		}
+	}
+	return receipts, nil
+}
+
+func (api *receiptHttpApi) land(hash common.Hash) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.receipts[hash.String()] = &types.Receipt{TBlockHash: hash, DBlockNumber: 1, Success: true}
+}
+
+func TestReceiptWaiter_Wait(t *testing.T) {
+	httpApi := &receiptHttpApi{receipts: make(map[string]*types.Receipt)}
+	waiter := NewReceiptWaiter(httpApi, nil, 10*time.Millisecond, 0)
+	defer waiter.Close()
+
+	hashes := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")}
+	var wg sync.WaitGroup
+	for _, hash := range hashes {
+		wg.Add(2)
+		for i := 0; i < 2; i++ {
+			go func(hash common.Hash) {
+				defer wg.Done()
+				receipt, err := waiter.Wait(context.Background(), chainId, hash)
+				assert.NoError(t, err)
+				assert.Equal(t, hash, receipt.TBlockHash)
+			}(hash)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	for _, hash := range hashes {
+		httpApi.land(hash)
+	}
+	wg.Wait()
+
+	// the poller exits once nothing is pending
+	calls := httpApi.calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, httpApi.calls.Load())
+}
+
+func TestReceiptWaiter_WaitCanceled(t *testing.T) {
+	httpApi := &receiptHttpApi{receipts: make(map[string]*types.Receipt)}
+	waiter := NewReceiptWaiter(httpApi, nil, 10*time.Millisecond, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err := waiter.Wait(ctx, chainId, common.HexToHash("0x01"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		_, err := waiter.Wait(context.Background(), chainId, common.HexToHash("0x02"))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	waiter.Close()
+	assert.ErrorIs(t, <-done, ErrReceiptWaiterClosed)
+}
+
+type slowReceiptHttpApi struct {
+	client.HttpApi
+	timeouts chan time.Duration
+}
+
+func (api *slowReceiptHttpApi) GetReceipts(ctx context.Context, _ string, _ []string) ([]*types.Receipt, error) {
+	deadline, _ := ctx.Deadline()
+	select {
+	case api.timeouts <- time.Until(deadline):
+	default:
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestReceiptWaiter_RequestTimeout(t *testing.T) {
+	httpApi := &slowReceiptHttpApi{timeouts: make(chan time.Duration, 1)}
+	waiter := NewReceiptWaiter(httpApi, nil, 10*time.Millisecond, 50*time.Millisecond)
+	defer waiter.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		_, _ = waiter.Wait(ctx, chainId, common.HexToHash("0x01"))
+	}()
+	assert.LessOrEqual(t, <-httpApi.timeouts, 50*time.Millisecond)
+}
@@ -0,0 +1,266 @@
+package lattice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedisKeyPrefix         = "lattice:"
+	defaultRedisLockLeaseTTL      = 10 * time.Second
+	defaultRedisLockRetryInterval = 50 * time.Millisecond
+	maxRedisLockBackoff           = 2 * time.Second
+	redisCommandTimeout           = 3 * time.Second
+)
+
+var (
+	// 获取锁，成功时递增并返回防护令牌，KEYS[1]为锁，KEYS[2]为防护令牌，ARGV[1]为持有者标识，ARGV[2]为租约时长(ms)
+	obtainLockScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0`)
+
+	// 续约，仅当锁仍由持有者持有时延长租约
+	renewLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// 释放锁，仅当锁仍由持有者持有时删除
+	releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// FencingAccountLock 带防护令牌的账户锁，每次获取锁时防护令牌单调递增，
+// 区块缓存据此拒绝租约已过期的旧持有者的写入
+type FencingAccountLock interface {
+	ContextAccountLock
+
+	// FencingToken 获取当前进程持有的账户锁的防护令牌
+	//
+	// Parameters:
+	//   - chainId string: 链ID
+	//   - address string: 账户地址
+	//
+	// Returns:
+	//   - int64: 防护令牌
+	//   - bool: 当前进程未持有该账户锁时为false
+	FencingToken(chainId, address string) (int64, bool)
+
+	// LeaseLost 获取当前进程持有的账户锁的租约丢失信号，租约被其它进程取得或长时间无法续约时channel关闭，
+	// 此后不应再以该账户发交易
+	//
+	// Parameters:
+	//   - chainId string: 链ID
+	//   - address string: 账户地址
+	//
+	// Returns:
+	//   - <-chan struct{}
+	//   - bool: 当前进程未持有该账户锁时为false
+	LeaseLost(chainId, address string) (<-chan struct{}, bool)
+}
+
+// RedisAccountLockOptions Redis账户锁的配置
+type RedisAccountLockOptions struct {
+	KeyPrefix     string        // key的前缀，默认为 lattice:
+	LeaseTTL      time.Duration // 锁的租约时长，持有期间每1/3租约时长续约一次，进程崩溃时锁在租约到期后自动释放，默认10s
+	RetryInterval time.Duration // 锁被其它进程持有时的重试间隔，默认50ms，Redis出错时从该间隔开始指数退避，最长2s
+}
+
+// NewRedisAccountLock 初始化基于Redis的分布式账户锁，支持多个进程使用同一个账户并发发交易
+//
+// Parameters:
+//   - rdb redis.UniversalClient
+//   - options *RedisAccountLockOptions: 为nil时使用默认配置
+//
+// Returns:
+//   - FencingAccountLock
+func NewRedisAccountLock(rdb redis.UniversalClient, options *RedisAccountLockOptions) FencingAccountLock {
+	if options == nil {
+		options = new(RedisAccountLockOptions)
+	}
+	lock := &redisAccountLock{
+		rdb:           rdb,
+		keyPrefix:     options.KeyPrefix,
+		leaseTTL:      options.LeaseTTL,
+		retryInterval: options.RetryInterval,
+		localLock:     NewAccountLock(),
+		holds:         make(map[string]*redisLockHold),
+	}
+	if lock.keyPrefix == "" {
+		lock.keyPrefix = defaultRedisKeyPrefix
+	}
+	if lock.leaseTTL <= 0 {
+		lock.leaseTTL = defaultRedisLockLeaseTTL
+	}
+	if lock.retryInterval <= 0 {
+		lock.retryInterval = defaultRedisLockRetryInterval
+	}
+	return lock
+}
+
+type redisAccountLock struct {
+	rdb           redis.UniversalClient
+	keyPrefix     string
+	leaseTTL      time.Duration
+	retryInterval time.Duration
+	localLock     AccountLock // 进程内先获取本地锁，避免同一进程的协程争抢Redis锁
+
+	mu    sync.Mutex
+	holds map[string]*redisLockHold // 当前进程持有的锁，key为chainId_address
+}
+
+// redisLockHold 当前进程持有的一把锁
+type redisLockHold struct {
+	owner        string // 持有者标识，写入锁的value
+	fencingToken int64
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+	lostCh       chan struct{} // 租约丢失时关闭
+}
+
+func (l *redisAccountLock) lockKey(name string) string {
+	return fmt.Sprintf("%slock:%s", l.keyPrefix, name)
+}
+
+func (l *redisAccountLock) fencingKey(name string) string {
+	return fmt.Sprintf("%sfence:%s", l.keyPrefix, name)
+}
+
+// Obtain 获取账户锁，一直等待到获取成功，需要取消等待时使用 ObtainContext
+func (l *redisAccountLock) Obtain(chainId, address string) {
+	_ = l.ObtainContext(context.Background(), chainId, address)
+}
+
+func (l *redisAccountLock) ObtainContext(ctx context.Context, chainId, address string) error {
+	if err := obtainAccountLock(ctx, l.localLock, chainId, address); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s", chainId, address)
+	owner := newLockOwner()
+	backoff := l.retryInterval
+	for {
+		commandCtx, cancel := context.WithTimeout(ctx, redisCommandTimeout)
+		token, err := obtainLockScript.Run(commandCtx, l.rdb, []string{l.lockKey(name), l.fencingKey(name)}, owner, l.leaseTTL.Milliseconds()).Int64()
+		cancel()
+		interval := l.retryInterval
+		switch {
+		case err == nil && token > 0:
+			hold := &redisLockHold{owner: owner, fencingToken: token, stopCh: make(chan struct{}), doneCh: make(chan struct{}), lostCh: make(chan struct{})}
+			l.mu.Lock()
+			l.holds[name] = hold
+			l.mu.Unlock()
+			go l.renew(name, hold)
+			return nil
+		case err == nil:
+			// 锁被其它进程持有
+			backoff = l.retryInterval
+		case ctx.Err() == nil:
+			// Redis不可用时指数退避，避免所有等待者同时重试
+			interval, backoff = backoff, min(2*backoff, maxRedisLockBackoff)
+			logger.Warn("Failed to obtain the redis account lock, retrying later", "获取Redis账户锁失败，稍后重试", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Duration("interval", interval), logger.Err(err))
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.localLock.Unlock(chainId, address)
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *redisAccountLock) Unlock(chainId, address string) {
+	name := fmt.Sprintf("%s_%s", chainId, address)
+	l.mu.Lock()
+	hold, ok := l.holds[name]
+	delete(l.holds, name)
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	close(hold.stopCh)
+	<-hold.doneCh
+	ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+	defer cancel()
+	if released, err := releaseLockScript.Run(ctx, l.rdb, []string{l.lockKey(name)}, hold.owner).Int64(); err != nil {
+		logger.Error("Failed to release the redis account lock", "释放Redis账户锁失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+	} else if released == 0 {
+		logger.Warn("The lease of the redis account lock has expired", "Redis账户锁的租约已过期", logger.String("chainId", chainId), logger.String("accountAddress", address))
+	}
+	l.localLock.Unlock(chainId, address)
+}
+
+func (l *redisAccountLock) LeaseLost(chainId, address string) (<-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hold, ok := l.holds[fmt.Sprintf("%s_%s", chainId, address)]
+	if !ok {
+		return nil, false
+	}
+	return hold.lostCh, true
+}
+
+func (l *redisAccountLock) FencingToken(chainId, address string) (int64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hold, ok := l.holds[fmt.Sprintf("%s_%s", chainId, address)]
+	if !ok {
+		return 0, false
+	}
+	return hold.fencingToken, true
+}
+
+// 持有期间定期续约，直到释放锁或租约丢失，租约丢失时关闭 redisLockHold.lostCh
+func (l *redisAccountLock) renew(name string, hold *redisLockHold) {
+	defer close(hold.doneCh)
+	ticker := time.NewTicker(l.leaseTTL / 3)
+	defer ticker.Stop()
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-hold.stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+			renewed, err := renewLockScript.Run(ctx, l.rdb, []string{l.lockKey(name)}, hold.owner, l.leaseTTL.Milliseconds()).Int64()
+			cancel()
+			switch {
+			case err == nil && renewed > 0:
+				renewedAt = time.Now()
+				continue
+			case err != nil && time.Since(renewedAt) < l.leaseTTL:
+				logger.Warn("Failed to renew the redis account lock", "Redis账户锁续约失败", logger.String("lock", name), logger.Err(err))
+				continue
+			case err != nil:
+				// 租约已过期，锁可能已被其它进程取得
+				logger.Error("The redis account lock could not be renewed before the lease expired", "Redis账户锁在租约到期前未能续约", logger.String("lock", name), logger.Err(err))
+			default:
+				logger.Error("The lease of the redis account lock was lost", "Redis账户锁的租约已丢失", logger.String("lock", name))
+			}
+			close(hold.lostCh)
+			return
+		}
+	}
+}
+
+func newLockOwner() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,168 @@
+package lattice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"time"
+)
+
+const (
+	defaultRedisBlockLifeDuration       = time.Minute
+	defaultRedisDaemonHashExpirationTTL = 10 * time.Second
+)
+
+// ErrStaleFencingToken 写入区块缓存的防护令牌小于缓存中的令牌，说明账户锁的租约已过期并被其它进程获取
+var ErrStaleFencingToken = errors.New("stale fencing token, the account lock has been taken over")
+
+// 写入区块，防护令牌小于已写入的令牌时拒绝写入，KEYS[1]为区块，ARGV[1]为区块json，ARGV[2]为防护令牌，ARGV[3]为存活时长(ms)
+var setBlockScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], 'fence') or '0')
+local fence = tonumber(ARGV[2])
+if fence > 0 and fence < current then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'block', ARGV[1], 'fence', math.max(fence, current))
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1`)
+
+// RedisBlockCacheOptions Redis区块缓存的配置
+type RedisBlockCacheOptions struct {
+	KeyPrefix                    string        // key的前缀，默认为 lattice:，需要与账户锁一致
+	LifeDuration                 time.Duration // 缓存的存活时长，默认1min
+	DaemonHashExpirationDuration time.Duration // 守护区块哈希的过期时长，默认10s
+}
+
+// NewRedisBlockCache 初始化基于Redis的区块缓存，多个进程共享账户的最新区块
+//
+// Parameters:
+//   - rdb redis.UniversalClient
+//   - lock FencingAccountLock: 不为nil时，写入缓存时携带账户锁的防护令牌，拒绝租约过期的旧持有者的写入
+//   - options *RedisBlockCacheOptions: 为nil时使用默认配置
+//
+// Returns:
+//   - BlockCache
+func NewRedisBlockCache(rdb redis.UniversalClient, lock FencingAccountLock, options *RedisBlockCacheOptions) BlockCache {
+	if options == nil {
+		options = new(RedisBlockCacheOptions)
+	}
+	c := &redisBlockCache{
+		rdb:                          rdb,
+		lock:                         lock,
+		keyPrefix:                    options.KeyPrefix,
+		lifeDuration:                 options.LifeDuration,
+		daemonHashExpirationDuration: options.DaemonHashExpirationDuration,
+	}
+	if c.keyPrefix == "" {
+		c.keyPrefix = defaultRedisKeyPrefix
+	}
+	if c.lifeDuration <= 0 {
+		c.lifeDuration = defaultRedisBlockLifeDuration
+	}
+	if c.daemonHashExpirationDuration <= 0 {
+		c.daemonHashExpirationDuration = defaultRedisDaemonHashExpirationTTL
+	}
+	return c
+}
+
+type redisBlockCache struct {
+	rdb                          redis.UniversalClient
+	lock                         FencingAccountLock // 账户锁，提供防护令牌
+	httpApi                      client.HttpApi     // 节点的http客户端
+	keyPrefix                    string             // key的前缀
+	lifeDuration                 time.Duration      // 缓存的存活时长
+	daemonHashExpirationDuration time.Duration      // 守护区块哈希的过期时长
+}
+
+func (c *redisBlockCache) blockKey(chainId, address string) string {
+	return fmt.Sprintf("%sblock:%s_%s", c.keyPrefix, chainId, address)
+}
+
+// 守护区块哈希的有效标记，过期后需要从节点刷新守护区块哈希
+func (c *redisBlockCache) daemonHashKey(chainId string) string {
+	return fmt.Sprintf("%sdaemon:%s", c.keyPrefix, chainId)
+}
+
+func (c *redisBlockCache) SetHttpApi(httpApi client.HttpApi) {
+	c.httpApi = httpApi
+}
+
+func (c *redisBlockCache) SetBlock(chainId, address string, block *types.LatestBlock) error {
+	bytes, err := json.Marshal(block)
+	if err != nil {
+		logger.Error("Failed to serialize the block", "json序列化block失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return err
+	}
+	var fencingToken int64
+	if c.lock != nil {
+		fencingToken, _ = c.lock.FencingToken(chainId, address)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+	defer cancel()
+	written, err := setBlockScript.Run(ctx, c.rdb, []string{c.blockKey(chainId, address)}, string(bytes), fencingToken, c.lifeDuration.Milliseconds()).Int64()
+	if err != nil {
+		logger.Error("Failed to set the block cache", "设置区块缓存信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return err
+	}
+	if written == 0 {
+		logger.Error("The fencing token of the account lock is stale, refusing to write the block cache", "账户锁的防护令牌已过期，拒绝写入区块缓存", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Any("fencingToken", fencingToken))
+		return ErrStaleFencingToken
+	}
+	if err := c.rdb.SetNX(ctx, c.daemonHashKey(chainId), 1, c.daemonHashExpirationDuration).Err(); err != nil {
+		logger.Error("Failed to set the expiration of the daemon block hash", "设置守护区块哈希的过期时间失败", logger.String("chainId", chainId), logger.Err(err))
+	}
+	return nil
+}
+
+func (c *redisBlockCache) GetBlock(chainId, address string) (*types.LatestBlock, error) {
+	block, _, err := c.GetBlockWithHit(chainId, address)
+	return block, err
+}
+
+func (c *redisBlockCache) GetBlockWithHit(chainId, address string) (*types.LatestBlock, bool, error) {
+	nodeCtx := client.WithAccountRouting(context.Background(), chainId, address)
+	ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+	defer cancel()
+	cacheBlockJson, err := c.rdb.HGet(ctx, c.blockKey(chainId, address), "block").Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			block, err := c.httpApi.GetLatestBlock(nodeCtx, chainId, address)
+			return block, false, err
+		}
+		logger.Error("Failed to get the block cache", "获取区块缓存信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return nil, false, err
+	}
+	cacheBlock := new(types.LatestBlock)
+	if err := json.Unmarshal([]byte(cacheBlockJson), cacheBlock); err != nil {
+		logger.Error("Failed to serialize the block", "json序列化block失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+		return nil, false, err
+	}
+
+	// judge daemon hash expiration time
+	exists, err := c.rdb.Exists(ctx, c.daemonHashKey(chainId)).Result()
+	if err != nil {
+		logger.Error("Failed to get the expiration of the daemon block hash", "获取守护区块哈希的过期时间失败", logger.String("chainId", chainId), logger.Err(err))
+		return nil, false, err
+	}
+	if exists == 0 {
+		logger.Debug("The daemon block hash has expired, updating it", "守护区块哈希已过期，开始更新守护区块哈希", logger.String("chainId", chainId), logger.String("accountAddress", address))
+		block, err := c.httpApi.GetLatestBlock(nodeCtx, chainId, address)
+		if err != nil {
+			logger.Error("Failed to get the latest block from the node", "请求节点获取最新区块信息失败", logger.String("chainId", chainId), logger.String("accountAddress", address), logger.Err(err))
+			return nil, false, err
+		}
+		if err := c.rdb.Set(ctx, c.daemonHashKey(chainId), 1, c.daemonHashExpirationDuration).Err(); err != nil {
+			logger.Error("Failed to set the expiration of the daemon block hash", "设置守护区块哈希的过期时间失败", logger.String("chainId", chainId), logger.Err(err))
+		}
+		cacheBlock.DaemonBlockHash = block.DaemonBlockHash
+		return cacheBlock, false, nil
+	}
+
+	return cacheBlock, true, nil
+}
@@ -0,0 +1,155 @@
+package lattice
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/lattice/client"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type latestBlockHttpApi struct {
+	client.HttpApi
+	calls atomic.Int32
+}
+
+func (api *latestBlockHttpApi) GetLatestBlock(context.Context, string, string) (*types.LatestBlock, error) {
+	api.calls.Add(1)
+	return &types.LatestBlock{Height: 1, DaemonBlockHash: common.HexToHash("0xd1")}, nil
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	mr := miniredis.RunT(t)
+	return mr, redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestRedisAccountLock_MutualExclusion(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	// two locks sharing one redis simulate two processes
+	locks := []AccountLock{
+		NewRedisAccountLock(rdb, &RedisAccountLockOptions{RetryInterval: time.Millisecond}),
+		NewRedisAccountLock(rdb, &RedisAccountLockOptions{RetryInterval: time.Millisecond}),
+	}
+
+	var inside, maxInside atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(lock AccountLock) {
+			defer wg.Done()
+			lock.Obtain(chainId, credentials.AccountAddress)
+			defer lock.Unlock(chainId, credentials.AccountAddress)
+			if n := inside.Add(1); n > maxInside.Load() {
+				maxInside.Store(n)
+			}
+			time.Sleep(2 * time.Millisecond)
+			inside.Add(-1)
+		}(locks[i%2])
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxInside.Load())
+}
+
+func TestRedisAccountLock_Renewal(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	lock := NewRedisAccountLock(rdb, &RedisAccountLockOptions{LeaseTTL: 300 * time.Millisecond})
+	lock.Obtain(chainId, credentials.AccountAddress)
+	defer lock.Unlock(chainId, credentials.AccountAddress)
+
+	key := defaultRedisKeyPrefix + "lock:" + chainId + "_" + credentials.AccountAddress
+	mr.FastForward(200 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return mr.TTL(key) == 300*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedisAccountLock_ObtainContext(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	holder := NewRedisAccountLock(rdb, &RedisAccountLockOptions{RetryInterval: time.Millisecond})
+	waiter := NewRedisAccountLock(rdb, &RedisAccountLockOptions{RetryInterval: time.Millisecond})
+	assert.NoError(t, holder.ObtainContext(context.Background(), chainId, credentials.AccountAddress))
+
+	// 锁被其它进程持有，等待到ctx超时，并释放本地锁
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, waiter.ObtainContext(ctx, chainId, credentials.AccountAddress), context.DeadlineExceeded)
+	_, ok := waiter.FencingToken(chainId, credentials.AccountAddress)
+	assert.False(t, ok)
+
+	holder.Unlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, waiter.ObtainContext(context.Background(), chainId, credentials.AccountAddress))
+	waiter.Unlock(chainId, credentials.AccountAddress)
+
+	// Redis不可用时退避重试，直到ctx超时
+	_ = rdb.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, waiter.ObtainContext(ctx, chainId, credentials.AccountAddress), context.DeadlineExceeded)
+}
+
+func TestRedisAccountLock_LeaseLost(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	lock := NewRedisAccountLock(rdb, &RedisAccountLockOptions{LeaseTTL: 90 * time.Millisecond})
+	_, ok := lock.LeaseLost(chainId, credentials.AccountAddress)
+	assert.False(t, ok)
+
+	lock.Obtain(chainId, credentials.AccountAddress)
+	defer lock.Unlock(chainId, credentials.AccountAddress)
+	lost, ok := lock.LeaseLost(chainId, credentials.AccountAddress)
+	assert.True(t, ok)
+
+	// 锁被删除，续约时发现租约丢失
+	mr.Del(defaultRedisKeyPrefix + "lock:" + chainId + "_" + credentials.AccountAddress)
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("the lease loss was not signalled")
+	}
+}
+
+func TestRedisBlockCache_Fencing(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	httpApi := new(latestBlockHttpApi)
+	oldLock := NewRedisAccountLock(rdb, &RedisAccountLockOptions{LeaseTTL: time.Hour, RetryInterval: time.Millisecond})
+	newLock := NewRedisAccountLock(rdb, &RedisAccountLockOptions{LeaseTTL: time.Hour, RetryInterval: time.Millisecond})
+	oldCache := NewRedisBlockCache(rdb, oldLock, nil)
+	oldCache.SetHttpApi(httpApi)
+	newCache := NewRedisBlockCache(rdb, newLock, nil)
+	newCache.SetHttpApi(httpApi)
+
+	// cache miss falls back to the node
+	block, err := oldCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), block.Height)
+	assert.Equal(t, int32(1), httpApi.calls.Load())
+
+	oldLock.Obtain(chainId, credentials.AccountAddress)
+	token, ok := oldLock.FencingToken(chainId, credentials.AccountAddress)
+	assert.True(t, ok)
+	assert.NoError(t, oldCache.SetBlock(chainId, credentials.AccountAddress, &types.LatestBlock{Height: 2}))
+
+	// the lease of the old holder expires, another process takes over the account
+	mr.FastForward(2 * time.Hour)
+	newLock.Obtain(chainId, credentials.AccountAddress)
+	newToken, _ := newLock.FencingToken(chainId, credentials.AccountAddress)
+	assert.Greater(t, newToken, token)
+	assert.NoError(t, newCache.SetBlock(chainId, credentials.AccountAddress, &types.LatestBlock{Height: 3}))
+
+	// the late write of the old holder is rejected
+	assert.ErrorIs(t, oldCache.SetBlock(chainId, credentials.AccountAddress, &types.LatestBlock{Height: 4}), ErrStaleFencingToken)
+	oldLock.Unlock(chainId, credentials.AccountAddress)
+	newLock.Unlock(chainId, credentials.AccountAddress)
+
+	// the daemon hash is refreshed from the node after expiration
+	mr.FastForward(defaultRedisDaemonHashExpirationTTL)
+	block, err = newCache.GetBlock(chainId, credentials.AccountAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), block.Height)
+	assert.Equal(t, common.HexToHash("0xd1"), block.DaemonBlockHash)
+}
@@ -0,0 +1,189 @@
+package lattice
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/samber/lo"
+	"github.com/wylu1037/lattice-go/common/constant"
+	"github.com/wylu1037/lattice-go/common/convert"
+	"github.com/wylu1037/lattice-go/common/logger"
+	"github.com/wylu1037/lattice-go/common/types"
+	"github.com/wylu1037/lattice-go/crypto"
+	"github.com/wylu1037/lattice-go/lattice/block"
+)
+
+// TxRequest 通用的交易请求，新增的交易类型只需要在 block.TransactionTypeCode 中注册，无需新增接口方法
+//   - Type    交易类型
+//   - Linker  转账的接收地址或合约地址，部署合约时为空，默认使用零地址
+//   - Code    合约代码或调用合约的数据，16进制带0x前缀的字符串，不为空时计算CodeHash
+//   - Payload 交易备注，16进制带0x前缀的字符串
+//   - Amount  转账额度
+//   - Joule   交易手续费，为0时使用 ChainConfig.DefaultJoule
+type TxRequest struct {
+	Type    block.TransactionType
+	Linker  string
+	Code    string
+	Payload string
+	Amount  uint64
+	Joule   uint64
+}
+
+// SendOption 发送交易的可选项
+type SendOption func(*sendOptions)
+
+type sendOptions struct {
+	waitReceipt   bool
+	retryStrategy *RetryStrategy
+}
+
+// WithWaitReceipt 发送交易后等待回执
+//
+// Parameters:
+//   - retryStrategy *RetryStrategy: 等待回执策略
+//
+// Returns:
+//   - SendOption
+func WithWaitReceipt(retryStrategy *RetryStrategy) SendOption {
+	return func(options *sendOptions) {
+		options.waitReceipt = true
+		options.retryStrategy = retryStrategy
+	}
+}
+
+// 部署合约的交易类型，未指定Linker时使用零地址
+func isDeployTransactionType(transactionType block.TransactionType) bool {
+	switch transactionType {
+	case block.TransactionTypeDeployContract, block.TransactionTypeDeployGoContract, block.TransactionTypeDeployJavaContract:
+		return true
+	default:
+		return false
+	}
+}
+
+func (svc *lattice) SendTransaction(ctx context.Context, signer crypto.Signer, chainId string, request *TxRequest, opts ...SendOption) (*common.Hash, *types.Receipt, error) {
+	chainConfig, err := svc.GetChainConfig(ctx, chainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signer.Curve() != chainConfig.Curve {
+		return nil, nil, fmt.Errorf("the curve of signer %s does not match the chain curve %s", signer.Curve(), chainConfig.Curve)
+	}
+	return svc.sendTransaction(ctx, chainConfig, signer, convert.AddressToZltc(signer.Address()), chainId, request, opts...)
+}
+
+// 使用凭证发送交易，凭证中的账户地址作为交易的Owner
+func (svc *lattice) sendTransactionWithCredentials(ctx context.Context, credentials *Credentials, chainId string, request *TxRequest, opts ...SendOption) (*common.Hash, *types.Receipt, error) {
+	chainConfig, err := svc.GetChainConfig(ctx, chainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, err := credentials.GetSigner(chainConfig.Curve)
+	if err != nil {
+		svc.logger.Error("Failed to get the signer", "获取账户的签名者失败", logger.String("accountAddress", credentials.AccountAddress), logger.Err(err))
+		return nil, nil, err
+	}
+	return svc.sendTransaction(ctx, chainConfig, signer, credentials.AccountAddress, chainId, request, opts...)
+}
+
+func (svc *lattice) sendTransaction(ctx context.Context, chainConfig *ChainConfig, signer crypto.Signer, owner, chainId string, request *TxRequest, opts ...SendOption) (*common.Hash, *types.Receipt, error) {
+	options := new(sendOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	// 复制一份，钩子可以修改交易请求
+	tx := &TxContext{ChainId: chainId, Owner: owner, Request: lo.ToPtr(*request)}
+	if err := svc.runTxHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.BeforeBuild }); err != nil {
+		svc.logger.Error("The transaction was aborted by a hook", "交易被钩子中止", logger.String("chainId", chainId), logger.String("owner", owner), logger.Err(err))
+		return nil, nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	request = tx.Request
+	// 钩子可以修改交易类型，在其后验证
+	if _, ok := block.TransactionTypeCode[request.Type]; !ok {
+		return nil, nil, svc.runTxErrorHooks(ctx, tx, fmt.Errorf("unsupported transaction type: %s", request.Type))
+	}
+	svc.logger.Debug("Start sending the transaction", "开始发起交易", logger.Any("type", request.Type), logger.String("chainId", chainId), logger.String("owner", owner), logger.String("linker", request.Linker), logger.String("code", request.Code), logger.String("payload", request.Payload), logger.Uint64("amount", request.Amount), logger.Uint64("joule", request.Joule))
+
+	joule := request.Joule
+	if joule == 0 {
+		joule = chainConfig.DefaultJoule
+	}
+	linker := request.Linker
+	if linker == "" && isDeployTransactionType(request.Type) {
+		linker = constant.ZeroAddress
+	}
+	codeHash, err := svc.computeCodeHash(signer.Curve(), request.Code)
+	if err != nil {
+		return nil, nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+
+	hash, err := func() (*common.Hash, error) {
+		if err := obtainAccountLock(ctx, svc.accountLock, chainId, owner); err != nil {
+			svc.logger.Error("Failed to obtain the account lock", "获取账户锁失败", logger.String("chainId", chainId), logger.String("accountAddress", owner), logger.Err(err))
+			return nil, err
+		}
+		defer svc.accountLock.Unlock(chainId, owner)
+
+		latestBlock, err := svc.blockCache.GetBlock(chainId, owner)
+		if err != nil {
+			svc.logger.Error("Failed to get the latest block", "获取账户的最新区块失败", logger.String("chainId", chainId), logger.String("accountAddress", owner), logger.Err(err))
+			return nil, err
+		}
+
+		tx.Transaction = block.NewTransactionBuilder(request.Type).
+			SetLatestBlock(latestBlock).
+			SetOwner(owner).
+			SetLinker(linker).
+			SetCode(request.Code).
+			SetPayload(request.Payload).
+			SetAmount(request.Amount).
+			SetJoule(joule).
+			Build()
+		tx.Transaction.CodeHash = codeHash
+		if err := svc.runTxHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.BeforeSign }); err != nil {
+			svc.logger.Error("The transaction was aborted by a hook", "交易被钩子中止", logger.String("chainId", chainId), logger.String("owner", owner), logger.Err(err))
+			return nil, err
+		}
+		// 钩子可以修改Code，签名前重新计算CodeHash
+		if tx.Transaction.CodeHash, err = svc.computeCodeHash(signer.Curve(), tx.Transaction.Code); err != nil {
+			return nil, err
+		}
+
+		return svc.handleTransaction(ctx, chainConfig, signer, tx, latestBlock)
+	}()
+	if err != nil {
+		return nil, nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	svc.logger.Debug("Finish sending the transaction", "结束交易", logger.Any("type", request.Type), logger.Stringer("hash", hash))
+	tx.Hash = hash
+	svc.runTxNotifyHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.AfterSend })
+
+	if !options.waitReceipt {
+		return hash, nil, nil
+	}
+	hash, receipt, err := svc.waitReceipt(ctx, chainId, hash, options.retryStrategy)
+	if err != nil {
+		return hash, nil, svc.runTxErrorHooks(ctx, tx, err)
+	}
+	tx.Receipt = receipt
+	svc.runTxNotifyHooks(ctx, tx, func(hooks *TxHooks) func(context.Context, *TxContext) error { return hooks.OnReceipt })
+	return hash, receipt, nil
+}
+
+// 计算交易合约代码的哈希，代码为空时返回零值哈希
+func (svc *lattice) computeCodeHash(curve types.Curve, code string) (common.Hash, error) {
+	if code == "" {
+		return common.Hash{}, nil
+	}
+	decoded, err := hexutil.Decode(code)
+	if err != nil {
+		svc.logger.Error("The code of the transaction is not a valid hex string", "交易的合约代码不是合法的16进制字符串", logger.Err(err))
+		return common.Hash{}, err
+	}
+	api, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return api.Hash(decoded), nil
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"github.com/wylu1037/lattice-go/common/convert"
 	"github.com/wylu1037/lattice-go/common/types"
 	"github.com/wylu1037/lattice-go/crypto"
@@ -87,7 +88,10 @@ func NewFileKey(fileKeyJsonString string) *FileKey {
 //   - *FileKey
 //   - error
 func GenerateFileKey(privateKey, passphrase string, curve types.Curve) (*FileKey, error) {
-	instance := crypto.NewCrypto(curve)
+	instance, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
 	secretKey, err := instance.HexToSK(privateKey)
 	if err != nil {
 		return nil, err
@@ -122,6 +126,10 @@ func GenerateFileKey(privateKey, passphrase string, curve types.Curve) (*FileKey
 //   - *Cipher
 //   - error
 func GenCipher(privateKey, passphrase string, curve types.Curve) (*Cipher, error) {
+	instance, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
 	// generate salt
 	salt, err := random(32)
 	if err != nil {
@@ -144,7 +152,7 @@ func GenCipher(privateKey, passphrase string, curve types.Curve) (*Cipher, error
 		return nil, err
 	}
 
-	mac := crypto.NewCrypto(curve).Hash(hashKey, ciphertext)
+	mac := instance.Hash(hashKey, ciphertext)
 
 	return &Cipher{
 		Aes: &Aes{
@@ -196,8 +204,12 @@ func (e *FileKey) Decrypt(passphrase string) (*ecdsa.PrivateKey, error) {
 	} else {
 		curve = crypto.Secp256k1
 	}
+	instance, err := crypto.GetCrypto(curve)
+	if err != nil {
+		return nil, err
+	}
 	hashKey := key[aes.BlockSize:32] // compact mac
-	actualMac := crypto.NewCrypto(curve).Hash(hashKey, ciphertext)
+	actualMac := instance.Hash(hashKey, ciphertext)
 	expectMac, err := hex.DecodeString(e.Cipher.Mac)
 	if err != nil {
 		return nil, err
@@ -215,7 +227,30 @@ func (e *FileKey) Decrypt(passphrase string) (*ecdsa.PrivateKey, error) {
 		return nil, err
 	}
 
-	return crypto.NewCrypto(curve).BytesToSK(privateKey)
+	return instance.BytesToSK(privateKey)
+}
+
+// Signer 解密FileKey并返回签名者，私钥仅以 *ecdsa.PrivateKey 的形式保存在签名者中
+//
+// Parameters:
+//   - passphrase string: 身份密码
+//
+// Returns:
+//   - crypto.Signer
+//   - error
+func (e *FileKey) Signer(passphrase string) (crypto.Signer, error) {
+	privateKey, err := e.Decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := crypto.NewMemorySigner(lo.Ternary(e.IsGM, crypto.Sm2p256v1, crypto.Secp256k1), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if e.Address != "" && convert.AddressToZltc(signer.Address()) != e.Address {
+		return nil, fmt.Errorf("the private key does not match the address %s", e.Address)
+	}
+	return signer, nil
 }
 
 // 使用KDF中的script(基于密码的密钥导出算法（Password-Based Key Derivation Function, KDF），其主要作用是通过消耗大量内存和计算资源来增强密码的安全性，防止暴力破解和专用硬件攻击)